@@ -0,0 +1,60 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gerrytan/azsubsyn/internal/plan"
+	"github.com/gerrytan/azsubsyn/internal/targets"
+)
+
+// applyFleet applies each target's own plan file one target at a time and prints a per-target summary.
+func applyFleet(ctx context.Context, targetSelection *targets.Selection, opts *applyOptions) error {
+	targetConfigs, err := targetSelection.Resolve(ctx)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to resolve target subscriptions: %w", err)
+	}
+
+	summaries := []string{}
+	failedTargets := 0
+
+	for _, targetConfig := range targetConfigs {
+		planFile := plan.FleetPlanFileName(targetConfig.SubscriptionID)
+
+		fmt.Printf("📖 Applying %s to target subscription %s...\n", planFile, targetConfig.SubscriptionID)
+
+		targetPlan, err := plan.LoadPlan(planFile)
+		if err != nil {
+			failedTargets++
+			summaries = append(summaries, fmt.Sprintf("  - ❌ %s: %s", targetConfig.SubscriptionID, err))
+			continue
+		}
+
+		failed, err := applyPlan(ctx, targetConfig, targetPlan, opts)
+		if err != nil {
+			failedTargets++
+			summaries = append(summaries, fmt.Sprintf("  - ❌ %s: %s", targetConfig.SubscriptionID, err))
+			continue
+		}
+		if failed > 0 {
+			failedTargets++
+			summaries = append(summaries, fmt.Sprintf("  - ❌ %s: %d registrations failed", targetConfig.SubscriptionID, failed))
+			continue
+		}
+
+		summaries = append(summaries, fmt.Sprintf("  - ✅ %s: %d RPs, %d preview features", targetConfig.SubscriptionID,
+			len(targetPlan.RpRegistrations), len(targetPlan.PreviewFeatures)))
+	}
+
+	fmt.Println("📊 Fleet apply summary:")
+	for _, summary := range summaries {
+		fmt.Println(summary)
+	}
+
+	if failedTargets > 0 {
+		return fmt.Errorf("❌ Failed to apply %d of %d target subscriptions", failedTargets, len(targetConfigs))
+	}
+
+	fmt.Printf("✅ Plans applied successfully to %d target subscriptions!\n", len(targetConfigs))
+	return nil
+}
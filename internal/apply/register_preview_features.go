@@ -3,37 +3,86 @@ package apply
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
 	"github.com/gerrytan/azsubsyn/internal/config"
 	"github.com/gerrytan/azsubsyn/internal/credential"
 	"github.com/gerrytan/azsubsyn/internal/plan"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
 )
 
-func registerPreviewFeatures(config *config.Config, previewFeatures []plan.PreviewFeature) error {
+// registerPreviewFeatures registers the preview features, the ones registering or unregistering in target are waited
+// for first, all together up to waitTimeout.
+func registerPreviewFeatures(ctx context.Context, config *config.Config, previewFeatures []plan.PreviewFeature, waitTimeout time.Duration) (failed int, err error) {
 	if len(previewFeatures) == 0 {
 		fmt.Printf("ℹ️  No preview feature registrations required\n")
-		return nil
+		return 0, nil
 	}
 
 	cred, err := credential.BuildCredential(config)
 	if err != nil {
-		return fmt.Errorf("failed to build credentials: %w", err)
+		return 0, fmt.Errorf("failed to build credentials: %w", err)
 	}
 
-	client, err := armfeatures.NewClient(config.SubscriptionID, cred, nil)
+	client, err := armfeatures.NewClient(config.SubscriptionID, cred, credential.ClientOptions(config))
 	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+		return 0, fmt.Errorf("failed to create client: %w", err)
 	}
 
-	for _, feature := range previewFeatures {
-		fmt.Printf("  - Registering Preview Feature: %s/%s (Reason: %s)\n", feature.Namespace, feature.Key, feature.Reason)
+	reasons := make([]string, len(previewFeatures))
+	for i, feature := range previewFeatures {
+		reasons[i] = feature.Reason
+	}
+	waits, err := waitFor(ctx, "preview features", reasons, func(i int) func(ctx context.Context) (string, error) {
+		return func(ctx context.Context) (string, error) {
+			resp, err := client.Get(ctx, previewFeatures[i].Namespace, previewFeatures[i].Key, nil)
+			if err != nil {
+				return "", err
+			}
+			if resp.Properties == nil {
+				return "", nil
+			}
+			return pointer.From(resp.Properties.State), nil
+		}
+	}, waitTimeout)
+	if err != nil {
+		return 0, err
+	}
 
-		_, err := client.Register(context.Background(), feature.Namespace, feature.Key, nil)
+	for i, feature := range previewFeatures {
+		register, waitErr := true, error(nil)
+		if waits[i] != nil {
+			register, waitErr = waits[i].outcome()
+		}
+		if !register {
+			if waitErr != nil {
+				failed++
+				fmt.Printf("   ❌ Preview feature %s/%s can't be registered: %s\n", feature.Namespace, feature.Key, waitErr)
+			} else {
+				fmt.Printf("  - ✅ Preview feature %s/%s is now %s\n", feature.Namespace, feature.Key, waits[i].state)
+			}
+			continue
+		}
+
+		if waitErr != nil {
+			fmt.Printf("  - Re-triggering Preview Feature registration: %s/%s (Reason: %s)\n", feature.Namespace, feature.Key, feature.Reason)
+		} else {
+			fmt.Printf("  - Registering Preview Feature: %s/%s (Reason: %s)\n", feature.Namespace, feature.Key, feature.Reason)
+		}
+
+		_, err := client.Register(ctx, feature.Namespace, feature.Key, nil)
 		if err != nil {
+			failed++
 			fmt.Printf("   ❌ Failed to register preview feature %s/%s: %s\n", feature.Namespace, feature.Key, err)
+			continue
+		}
+
+		if waitErr != nil {
+			failed++
+			fmt.Printf("   ❌ Preview feature %s/%s was stuck registering, re-run apply to check it: %s\n", feature.Namespace, feature.Key, waitErr)
 		}
 	}
 
-	return nil
+	return failed, nil
 }
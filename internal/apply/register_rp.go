@@ -3,6 +3,7 @@ package apply
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/gerrytan/azsubsyn/internal/config"
@@ -11,26 +12,63 @@ import (
 	"github.com/gerrytan/azsubsyn/internal/pointer"
 )
 
-func registerRPs(config *config.Config, rpRegistrations []plan.RpRegistration) error {
+// registerRPs registers the RPs, the ones registering or unregistering in target are waited for first, all together
+// up to waitTimeout.
+func registerRPs(ctx context.Context, config *config.Config, rpRegistrations []plan.RpRegistration, waitTimeout time.Duration) (failed int, err error) {
 	if len(rpRegistrations) == 0 {
 		fmt.Printf("ℹ️  No resource provider registrations required\n")
-		return nil
+		return 0, nil
 	}
 
 	cred, err := credential.BuildCredential(config)
 	if err != nil {
-		return fmt.Errorf("failed to build credentials: %w", err)
+		return 0, fmt.Errorf("failed to build credentials: %w", err)
 	}
 
-	providersClient, err := armresources.NewProvidersClient(config.SubscriptionID, cred, nil)
+	providersClient, err := armresources.NewProvidersClient(config.SubscriptionID, cred, credential.ClientOptions(config))
 	if err != nil {
-		return fmt.Errorf("failed to create providers client: %w", err)
+		return 0, fmt.Errorf("failed to create providers client: %w", err)
 	}
 
-	for _, rpReg := range rpRegistrations {
-		fmt.Printf("  - Registering RP: %s (Reason: %s)\n", rpReg.Namespace, rpReg.Reason)
+	reasons := make([]string, len(rpRegistrations))
+	for i, rpReg := range rpRegistrations {
+		reasons[i] = rpReg.Reason
+	}
+	waits, err := waitFor(ctx, "RPs", reasons, func(i int) func(ctx context.Context) (string, error) {
+		return func(ctx context.Context) (string, error) {
+			resp, err := providersClient.Get(ctx, rpRegistrations[i].Namespace, nil)
+			if err != nil {
+				return "", err
+			}
+			return pointer.From(resp.RegistrationState), nil
+		}
+	}, waitTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, rpReg := range rpRegistrations {
+		register, waitErr := true, error(nil)
+		if waits[i] != nil {
+			register, waitErr = waits[i].outcome()
+		}
+		if !register {
+			if waitErr != nil {
+				failed++
+				fmt.Printf("   ❌ RP %s can't be registered: %s\n", rpReg.Namespace, waitErr)
+			} else {
+				fmt.Printf("  - ✅ RP %s is now %s\n", rpReg.Namespace, waits[i].state)
+			}
+			continue
+		}
 
-		_, err := providersClient.Register(context.Background(), rpReg.Namespace, &armresources.ProvidersClientRegisterOptions{
+		if waitErr != nil {
+			fmt.Printf("  - Re-triggering RP registration: %s (Reason: %s)\n", rpReg.Namespace, rpReg.Reason)
+		} else {
+			fmt.Printf("  - Registering RP: %s (Reason: %s)\n", rpReg.Namespace, rpReg.Reason)
+		}
+
+		_, err := providersClient.Register(ctx, rpReg.Namespace, &armresources.ProvidersClientRegisterOptions{
 			Properties: &armresources.ProviderRegistrationRequest{
 				ThirdPartyProviderConsent: &armresources.ProviderConsentDefinition{
 					ConsentToAuthorization: pointer.To(true),
@@ -38,10 +76,16 @@ func registerRPs(config *config.Config, rpRegistrations []plan.RpRegistration) e
 			},
 		})
 		if err != nil {
+			failed++
 			fmt.Printf("   ❌ Failed to register RP %s: %s\n", rpReg.Namespace, err)
+			continue
 		}
 
+		if waitErr != nil {
+			failed++
+			fmt.Printf("   ❌ RP %s was stuck registering, re-run apply to check it: %s\n", rpReg.Namespace, waitErr)
+		}
 	}
 
-	return nil
+	return failed, nil
 }
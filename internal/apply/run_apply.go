@@ -1,75 +1,252 @@
 package apply
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"time"
 
 	"github.com/gerrytan/azsubsyn/internal/config"
-	"github.com/gerrytan/azsubsyn/internal/jsonutil"
 	"github.com/gerrytan/azsubsyn/internal/plan"
+	"github.com/gerrytan/azsubsyn/internal/targets"
 )
 
 func RunApply() error {
-	if len(os.Args) < 3 {
-		printUsage()
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	fs.Usage = printUsage
+
+	var targetSelection targets.Selection
+	var opts applyOptions
+	targetSelection.RegisterFlags(fs)
+	fs.BoolVar(&opts.allowUnregister, "allow-unregister", false, "")
+	fs.BoolVar(&opts.strict, "strict", false, "")
+	fs.DurationVar(&opts.waitTimeout, "wait-timeout", defaultWaitTimeout, "")
+
+	args, err := parseInterspersed(fs, os.Args[2:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
 		os.Exit(1)
 	}
 
-	arg := os.Args[2]
+	// Ctrl+C stops waiting for registrations instead of killing apply halfway through its summary
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	switch arg {
-	case "help", "-h", "--help":
+	if len(args) == 1 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help") {
 		printUsage()
 		os.Exit(0)
+	}
 
-	default:
-		var plan plan.Plan
-		planFile := arg
-
-		_, targetConfig, err := config.BuildConfigs()
-		if err != nil {
-			return fmt.Errorf("❌ Failed to build configuration: %w", err)
+	if targetSelection.IsSet() {
+		if len(args) > 0 {
+			printUsage()
+			os.Exit(1)
 		}
+		return applyFleet(ctx, &targetSelection, &opts)
+	}
 
-		fmt.Printf("📖 Registering RPs and feature to target subscription...\n")
-		fmt.Printf("  - Tenant ID: %s\n", targetConfig.TenantID)
-		fmt.Printf("  - Subscription ID: %s\n", targetConfig.SubscriptionID)
+	if len(args) != 1 {
+		printUsage()
+		os.Exit(1)
+	}
+	planFile := args[0]
 
-		data, err := os.ReadFile(planFile)
-		if err != nil {
-			return fmt.Errorf("❌ Failed to read plan file %s: %w", planFile, err)
-		}
+	targetConfig, err := config.BuildConfig("target")
+	if err != nil {
+		return fmt.Errorf("❌ Failed to build configuration: %w", err)
+	}
 
-		err = json.Unmarshal(jsonutil.StripJSONComments(data), &plan)
-		if err != nil {
-			return fmt.Errorf("❌ Failed to deserialize plan from %s: %w", planFile, err)
+	fmt.Printf("📖 Registering RPs and feature to target subscription...\n")
+	fmt.Printf("  - Tenant ID: %s\n", targetConfig.TenantID)
+	fmt.Printf("  - Subscription ID: %s\n", targetConfig.SubscriptionID)
+
+	plan, err := plan.LoadPlan(planFile)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to load plan: %w", err)
+	}
+	if !plan.Header.CreatedAt.IsZero() {
+		fmt.Printf("  - Plan created: %s by azsubsyn %s\n", plan.Header.CreatedAt.Format(time.RFC3339), plan.Header.ToolVersion)
+	}
+
+	failed, err := applyPlan(ctx, targetConfig, plan, &opts)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("❌ Failed to apply plan: %d operations failed", failed)
+	}
+
+	fmt.Printf("✅ Plan applied successfully!\n")
+	return nil
+}
+
+// applyOptions are the apply flags shared by single and fleet targets.
+type applyOptions struct {
+	allowUnregister bool
+	strict          bool          // abort on a stale plan instead of skipping the stale entries
+	waitTimeout     time.Duration // how long entries registering or unregistering in target are waited for
+}
+
+// applyPlan registers the plan entries to the target subscription, then unregisters the unregister entries, and
+// returns the number of failed operations. Entries the target changed for since planning are skipped first.
+func applyPlan(ctx context.Context, targetConfig *config.Config, targetPlan *plan.Plan, opts *applyOptions) (failed int, err error) {
+	targetPlan, err = checkStalePlan(ctx, targetConfig, targetPlan, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var rpRegs, rpUnregs []plan.RpRegistration
+	for _, rpReg := range targetPlan.RpRegistrations {
+		if rpReg.Reason == plan.ReasonUnavailableInTargetCloud {
+			fmt.Printf("  - ☁️  Skipping RP %s: not available in the target cloud\n", rpReg.Namespace)
+		} else if rpReg.Reason == plan.ReasonPendingInSource {
+			fmt.Printf("  - ⏸️  Skipping RP %s: still pending in source, re-create the plan once it is registered\n", rpReg.Namespace)
+		} else if rpReg.Action == "unregister" {
+			rpUnregs = append(rpUnregs, rpReg)
+		} else {
+			rpRegs = append(rpRegs, rpReg)
 		}
+	}
 
-		fmt.Printf("🔄 Registering %d RPs...\n", len(plan.RpRegistrations))
-		err = registerRPs(targetConfig, plan.RpRegistrations)
-		if err != nil {
-			return fmt.Errorf("❌ Failed to register RP: %w", err)
+	var featRegs, manualFeatRegs, featUnregs []plan.PreviewFeature
+	for _, feature := range targetPlan.PreviewFeatures {
+		if feature.Reason == plan.ReasonUnavailableInTargetCloud {
+			fmt.Printf("  - ☁️  Skipping preview feature %s/%s: not available in the target cloud\n", feature.Namespace, feature.Key)
+		} else if feature.Reason == plan.ReasonPendingInSource {
+			fmt.Printf("  - ⏸️  Skipping preview feature %s/%s: still pending in source, re-create the plan once it is registered\n", feature.Namespace, feature.Key)
+		} else if feature.Action == "unregister" {
+			featUnregs = append(featUnregs, feature)
+		} else if feature.Approval == plan.ApprovalManual {
+			manualFeatRegs = append(manualFeatRegs, feature)
+		} else {
+			featRegs = append(featRegs, feature)
 		}
+	}
+
+	if len(rpUnregs)+len(featUnregs) > 0 && !opts.allowUnregister {
+		return 0, fmt.Errorf("❌ Plan contains %d RP and %d preview feature unregistrations, re-run with --allow-unregister to apply them",
+			len(rpUnregs), len(featUnregs))
+	}
+
+	fmt.Printf("🔄 Registering %d RPs...\n", len(rpRegs))
+	rpFailed, err := registerRPs(ctx, targetConfig, rpRegs, opts.waitTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("❌ Failed to register RP: %w", err)
+	}
+
+	fmt.Printf("🔄 Registering %d preview features...\n", len(featRegs))
+	featFailed, err := registerPreviewFeatures(ctx, targetConfig, featRegs, opts.waitTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("❌ Failed to register preview feature: %w", err)
+	}
 
-		fmt.Printf("🔄 Registering %d preview features...\n", len(plan.PreviewFeatures))
-		err = registerPreviewFeatures(targetConfig, plan.PreviewFeatures)
+	failed = rpFailed + featFailed
+	if len(manualFeatRegs) > 0 {
+		manualFailed, err := registerManualPreviewFeatures(ctx, targetConfig, manualFeatRegs, opts.waitTimeout)
 		if err != nil {
-			return fmt.Errorf("❌ Failed to register preview feature: %w", err)
+			return 0, err
 		}
+		failed += manualFailed
+	}
 
-		fmt.Printf("✅ Plan applied successfully!\n")
+	if len(rpUnregs)+len(featUnregs) == 0 {
+		return failed, nil
+	}
 
+	fmt.Printf("🔍 Checking existing resources in target subscription...\n")
+	resourceCounts, err := plan.CountResourcesByNamespace(ctx, targetConfig)
+	if err != nil {
+		return 0, fmt.Errorf("❌ Failed to check existing resources: %w", err)
+	}
+
+	fmt.Printf("🔄 Unregistering %d preview features...\n", len(featUnregs))
+	featFailed, err = unregisterPreviewFeatures(ctx, targetConfig, featUnregs, targetPlan.Header.Protected, resourceCounts)
+	if err != nil {
+		return 0, fmt.Errorf("❌ Failed to unregister preview feature: %w", err)
+	}
+
+	fmt.Printf("🔄 Unregistering %d RPs...\n", len(rpUnregs))
+	rpFailed, err = unregisterRPs(ctx, targetConfig, rpUnregs, targetPlan.Header.Protected, resourceCounts)
+	if err != nil {
+		return 0, fmt.Errorf("❌ Failed to unregister RP: %w", err)
+	}
+
+	return failed + rpFailed + featFailed, nil
+}
+
+// registerManualPreviewFeatures registers the preview features needing Microsoft approval, which only leaves them
+// pending, and writes a support request draft for each so the approval can be requested.
+func registerManualPreviewFeatures(ctx context.Context, targetConfig *config.Config, previewFeatures []plan.PreviewFeature, waitTimeout time.Duration) (failed int, err error) {
+	fmt.Printf("🔄 Registering %d preview features needing Microsoft approval...\n", len(previewFeatures))
+	failed, err = registerPreviewFeatures(ctx, targetConfig, previewFeatures, waitTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("❌ Failed to register preview feature: %w", err)
+	}
+
+	paths, err := writeSupportRequestDrafts(targetConfig, previewFeatures)
+	if err != nil {
+		return 0, fmt.Errorf("❌ Failed to write support request drafts: %w", err)
+	}
+
+	fmt.Printf("📝 %d preview features stay pending until Microsoft approves them, file a support request for each:\n", len(previewFeatures))
+	for i, feature := range previewFeatures {
+		fmt.Printf("  - %s/%s: %s\n", feature.Namespace, feature.Key, paths[i])
+	}
+	return failed, nil
+}
+
+// parseInterspersed parses flags that may appear before or after positional arguments, eg: "apply plan.jsonc --strict".
+func parseInterspersed(fs *flag.FlagSet, args []string) (positional []string, err error) {
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
-	return nil
 }
 
 func printUsage() {
 	fmt.Println("azsubsyn apply - Apply the plan to the target Azure subscription")
 	fmt.Println()
 	fmt.Println("USAGE:")
-	fmt.Println("  azsubsyn apply <plan-file>")
+	fmt.Println("  azsubsyn apply <plan-file> [--allow-unregister] [--strict]")
+	fmt.Println("  azsubsyn apply --fleet <fleet-file>")
+	fmt.Println("  azsubsyn apply [--target-management-group <id>] [--target-name <glob>] [--target-name-regex <regex>] [--target-tag <key>[=<value>]]")
+	fmt.Println()
+	fmt.Println("OPTIONS:")
+	fmt.Println("  --allow-unregister              Execute the unregister entries of a plan created with `azsubsyn plan --mode mirror`.")
+	fmt.Println("                                  Protected RPs and RPs that still have resources in the target are always skipped,")
+	fmt.Println("                                  so are the preview features of their namespaces. Namespaces given to")
+	fmt.Println("                                  `azsubsyn plan --protect` are recorded in the plan and protected too")
+	fmt.Println("  --strict                        Abort when the plan is stale instead of skipping the stale entries, or when its")
+	fmt.Println("                                  entries were edited since planning")
+	fmt.Println("  --wait-timeout <duration>       How long RPs and preview features registering or unregistering in target are waited")
+	fmt.Println("                                  for before registering them, eg: 30m (default 15m)")
+	fmt.Println("  --fleet <file>                  Apply `azsubsyn-plan-<subscription-id>.jsonc` to every target subscription listed in a fleet file")
+	fmt.Println("  --target-management-group <id>  Apply `azsubsyn-plan-<subscription-id>.jsonc` to every active subscription under a management group")
+	fmt.Println("  --target-name <glob>            Same, to every active subscription whose display name matches, eg: 'prod-*'")
+	fmt.Println("  --target-name-regex <regex>     Same, to every active subscription whose display name matches the regular expression")
+	fmt.Println("  --target-tag <key>[=<value>]    Same, to every active subscription with the tag, repeatable, eg: env=prod")
 	fmt.Println()
 	fmt.Println("DESCRIPTION:")
 	fmt.Println("  Applies the plan that was generated by azsubsyn plan to the target Azure subscription.")
+	fmt.Println("  A plan created for another target subscription is refused. The current target state is fetched first,")
+	fmt.Println("  entries already satisfied or whose target state changed since planning are reported and skipped, or")
+	fmt.Println("  abort the apply with --strict.")
+	fmt.Println("  A plan containing unregister entries is refused unless --allow-unregister is given.")
+	fmt.Println("  Entries with the UnavailableInTargetCloud or PendingInSource reason are skipped. Entries with the")
+	fmt.Println("  UnregisteringInTarget reason are registered once the unregistration completes. Entries with the")
+	fmt.Println("  StuckRegisteringInTarget reason are waited for and registered again only when they don't complete in time.")
+	fmt.Println("  Preview features with the Manual approval are registered separately and stay pending until Microsoft approves")
+	fmt.Println("  them, a support request draft is written to azsubsyn-support-request-<subscription-id>-<namespace>-<key>.md for each.")
 }
@@ -0,0 +1,58 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/plan"
+)
+
+// checkStalePlan refuses a plan made for another target subscription, warns about entries edited since planning,
+// then compares the plan with the current target state and returns it without the stale entries. Edited entries and
+// stale entries are an error under --strict.
+func checkStalePlan(ctx context.Context, targetConfig *config.Config, targetPlan *plan.Plan, opts *applyOptions) (*plan.Plan, error) {
+	edited, err := targetPlan.EntriesEdited()
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to check plan content hash: %w", err)
+	}
+	if edited {
+		if opts.strict {
+			return nil, fmt.Errorf("❌ The plan entries were edited since planning, re-create the plan or re-run without --strict")
+		}
+		fmt.Printf("  - ⚠️  The plan entries were edited by hand since planning, they don't match the header contentHash\n")
+	}
+
+	plannedTarget := targetPlan.Header.Target.SubscriptionID
+	if plannedTarget == "" {
+		fmt.Printf("  - ⚠️  The plan doesn't record its target subscription, it can't be verified\n")
+	} else if !strings.EqualFold(plannedTarget, targetConfig.SubscriptionID) {
+		return nil, fmt.Errorf("❌ The plan was created for target subscription %s, not %s", plannedTarget, targetConfig.SubscriptionID)
+	}
+
+	targetState, err := plan.FetchRegistrations(ctx, targetConfig, "target")
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to get target subscription state: %w", err)
+	}
+
+	fresh, stale := plan.CheckStaleness(targetPlan, targetState)
+	if len(stale) == 0 {
+		return fresh, nil
+	}
+
+	fmt.Printf("🕰️  %d plan entries are stale, the target changed since planning:\n", len(stale))
+	for _, entry := range stale {
+		icon := "⚠️ "
+		if entry.Satisfied {
+			icon = "✅"
+		}
+		fmt.Printf("  - %s %s %s: %s\n", icon, entry.Kind, entry.Name, entry.Problem)
+	}
+
+	if opts.strict {
+		return nil, fmt.Errorf("❌ Plan is stale, re-create it or re-run without --strict to skip the stale entries")
+	}
+	fmt.Printf("  - Skipping the stale entries, re-run with --strict to abort instead\n")
+	return fresh, nil
+}
@@ -0,0 +1,55 @@
+package apply
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/plan"
+)
+
+// writeSupportRequestDrafts writes a support request draft for every preview feature needing Microsoft approval to
+// the working directory and returns the written file names. The registration itself only leaves the feature pending.
+func writeSupportRequestDrafts(config *config.Config, previewFeatures []plan.PreviewFeature) (paths []string, err error) {
+	for _, feature := range previewFeatures {
+		path := fmt.Sprintf("azsubsyn-support-request-%s-%s-%s.md", config.SubscriptionID, feature.Namespace, feature.Key)
+		if err := os.WriteFile(path, []byte(supportRequestDraft(config, feature)), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write support request draft %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func supportRequestDraft(config *config.Config, feature plan.PreviewFeature) string {
+	name := feature.Namespace + "/" + feature.Key
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Support request: approve preview feature %s\n\n", name)
+	fmt.Fprintf(&b, "- Issue type: Technical\n")
+	fmt.Fprintf(&b, "- Summary: Request approval of preview feature %s\n", name)
+	fmt.Fprintf(&b, "- Tenant ID: %s\n", config.TenantID)
+	fmt.Fprintf(&b, "- Subscription ID: %s\n", config.SubscriptionID)
+	fmt.Fprintf(&b, "- Resource provider namespace: %s\n", feature.Namespace)
+	fmt.Fprintf(&b, "- Feature name: %s\n", feature.Key)
+	if feature.FeatureMetadata != nil {
+		if feature.Description != "" {
+			fmt.Fprintf(&b, "- Feature description: %s\n", feature.Description)
+		}
+		if feature.DocumentationLink != "" {
+			fmt.Fprintf(&b, "- Documentation: %s\n", feature.DocumentationLink)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Description\n\n")
+	fmt.Fprintf(&b, "Please approve the registration of preview feature %s for subscription %s, the registration has been\n", name, config.SubscriptionID)
+	fmt.Fprintf(&b, "submitted and is pending approval.\n")
+	if len(feature.Sources) > 0 {
+		fmt.Fprintf(&b, "The feature is already registered in: %s.\n", strings.Join(feature.Sources, ", "))
+	}
+
+	fmt.Fprintf(&b, "\n## Justification\n\n")
+	fmt.Fprintf(&b, "<describe the workload needing the feature and why>\n")
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/credential"
+	"github.com/gerrytan/azsubsyn/internal/plan"
+)
+
+// unregisterPreviewFeatures unregisters preview features unless their namespace is protected or still has resources
+// in the target subscription, the resources may rely on the feature.
+func unregisterPreviewFeatures(ctx context.Context, config *config.Config, previewFeatures []plan.PreviewFeature, protected []string, resourceCounts map[string]int) (failed int, err error) {
+	if len(previewFeatures) == 0 {
+		fmt.Printf("ℹ️  No preview feature unregistrations required\n")
+		return 0, nil
+	}
+
+	cred, err := credential.BuildCredential(config)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build credentials: %w", err)
+	}
+
+	client, err := armfeatures.NewClient(config.SubscriptionID, cred, credential.ClientOptions(config))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	for _, feature := range previewFeatures {
+		if plan.IsProtectedNamespace(feature.Namespace, protected) {
+			fmt.Printf("  - 🛡️  Skipping preview feature of a protected namespace: %s/%s\n", feature.Namespace, feature.Key)
+			continue
+		}
+
+		if count := resourceCounts[strings.ToLower(feature.Namespace)]; count > 0 {
+			fmt.Printf("  - ⚠️  Skipping preview feature %s/%s: %d resources of its namespace exist in target\n", feature.Namespace, feature.Key, count)
+			continue
+		}
+
+		fmt.Printf("  - Unregistering Preview Feature: %s/%s (Reason: %s)\n", feature.Namespace, feature.Key, feature.Reason)
+
+		_, err := client.Unregister(ctx, feature.Namespace, feature.Key, nil)
+		if err != nil {
+			failed++
+			fmt.Printf("   ❌ Failed to unregister preview feature %s/%s: %s\n", feature.Namespace, feature.Key, err)
+		}
+	}
+
+	return failed, nil
+}
@@ -0,0 +1,54 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/credential"
+	"github.com/gerrytan/azsubsyn/internal/plan"
+)
+
+// unregisterRPs unregisters RPs unless they are protected or still have resources in the target subscription, those
+// are skipped with a warning since the plan file may have been edited or resources created after planning.
+// resourceCounts is keyed by lower-cased namespace as returned by CountResourcesByNamespace.
+func unregisterRPs(ctx context.Context, config *config.Config, rpUnregistrations []plan.RpRegistration, protected []string, resourceCounts map[string]int) (failed int, err error) {
+	if len(rpUnregistrations) == 0 {
+		fmt.Printf("ℹ️  No resource provider unregistrations required\n")
+		return 0, nil
+	}
+
+	cred, err := credential.BuildCredential(config)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build credentials: %w", err)
+	}
+
+	providersClient, err := armresources.NewProvidersClient(config.SubscriptionID, cred, credential.ClientOptions(config))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create providers client: %w", err)
+	}
+
+	for _, rpUnreg := range rpUnregistrations {
+		if plan.IsProtectedNamespace(rpUnreg.Namespace, protected) {
+			fmt.Printf("  - 🛡️  Skipping protected RP: %s\n", rpUnreg.Namespace)
+			continue
+		}
+
+		if count := resourceCounts[strings.ToLower(rpUnreg.Namespace)]; count > 0 {
+			fmt.Printf("  - ⚠️  Skipping RP %s: %d resources of this provider exist in target\n", rpUnreg.Namespace, count)
+			continue
+		}
+
+		fmt.Printf("  - Unregistering RP: %s (Reason: %s)\n", rpUnreg.Namespace, rpUnreg.Reason)
+
+		_, err := providersClient.Unregister(ctx, rpUnreg.Namespace, nil)
+		if err != nil {
+			failed++
+			fmt.Printf("   ❌ Failed to unregister RP %s: %s\n", rpUnreg.Namespace, err)
+		}
+	}
+
+	return failed, nil
+}
@@ -0,0 +1,108 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gerrytan/azsubsyn/internal/plan"
+)
+
+const (
+	waitInterval       = 10 * time.Second
+	defaultWaitTimeout = 15 * time.Minute
+)
+
+// waitEntry is an RP or preview feature in a transitional state in target, waited for before registering it.
+type waitEntry struct {
+	getState  func(ctx context.Context) (string, error)
+	waitWhile plan.RegistrationState // Unregistering | Registering
+
+	state plan.RegistrationState // last state seen
+	err   error                  // set when the state couldn't be read or didn't change in time
+}
+
+// newWaitEntry returns the entry to wait for given the plan reason, or nil when the reason needs no wait.
+func newWaitEntry(reason string, getState func(ctx context.Context) (string, error)) *waitEntry {
+	switch reason {
+	case plan.ReasonUnregisteringInTarget:
+		return &waitEntry{getState: getState, waitWhile: plan.StateUnregistering}
+	case plan.ReasonStuckRegisteringInTarget:
+		return &waitEntry{getState: getState, waitWhile: plan.StateRegistering}
+	default:
+		return nil
+	}
+}
+
+// waitAll polls every entry together until each one leaves its transitional state, the timeout elapses or the context
+// is done. Entries still transitional at the timeout are given an error, the context error is returned as is so callers
+// stop instead of registering with a cancelled context.
+func waitAll(ctx context.Context, entries []*waitEntry, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	pending := entries
+	for {
+		var next []*waitEntry
+		for _, entry := range pending {
+			rawState, err := entry.getState(ctx)
+			if err != nil {
+				entry.err = err
+				continue
+			}
+			entry.state = plan.ParseRegistrationState(rawState)
+			if entry.state == entry.waitWhile {
+				next = append(next, entry)
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pending = next
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			for _, entry := range pending {
+				entry.err = fmt.Errorf("still %s after %s", entry.state, timeout)
+			}
+			return nil
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// outcome tells what to do with an entry once waited for, the same rule applies to RPs and preview features: an entry
+// that ended Registered or Pending is done, any other state is registered. An entry still registering is registered
+// again to re-trigger it but still counted as failed, an entry still unregistering can't be registered yet.
+func (w *waitEntry) outcome() (register bool, err error) {
+	if w.err != nil {
+		return w.state == plan.StateRegistering, w.err
+	}
+	return !w.state.IsRegisteredOrPending(), nil
+}
+
+// waitFor waits for the entries needing it and returns the wait entry of each, nil for entries that weren't waited for.
+// It fails when the context is done, eg: on Ctrl+C.
+func waitFor(ctx context.Context, kind string, reasons []string, getState func(i int) func(ctx context.Context) (string, error), timeout time.Duration) ([]*waitEntry, error) {
+	waits := make([]*waitEntry, len(reasons))
+	var entries []*waitEntry
+	for i, reason := range reasons {
+		if waits[i] = newWaitEntry(reason, getState(i)); waits[i] != nil {
+			entries = append(entries, waits[i])
+		}
+	}
+	if len(entries) == 0 {
+		return waits, nil
+	}
+
+	fmt.Printf("  - ⏳ Waiting up to %s for %d %s to finish registering or unregistering...\n", timeout, len(entries), kind)
+	if err := waitAll(ctx, entries, timeout); err != nil {
+		return nil, fmt.Errorf("interrupted while waiting for %s: %w", kind, err)
+	}
+	return waits, nil
+}
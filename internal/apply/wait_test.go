@@ -0,0 +1,75 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gerrytan/azsubsyn/internal/plan"
+)
+
+func fixedState(state string, err error) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		return state, err
+	}
+}
+
+func TestWaitAll(t *testing.T) {
+	unregistered := newWaitEntry(plan.ReasonUnregisteringInTarget, fixedState("Unregistered", nil))
+	registered := newWaitEntry(plan.ReasonStuckRegisteringInTarget, fixedState("registered", nil))
+	pending := newWaitEntry(plan.ReasonStuckRegisteringInTarget, fixedState("Pending", nil))
+	stillRegistering := newWaitEntry(plan.ReasonStuckRegisteringInTarget, fixedState("Registering", nil))
+	stillUnregistering := newWaitEntry(plan.ReasonUnregisteringInTarget, fixedState("Unregistering", nil))
+	failing := newWaitEntry(plan.ReasonUnregisteringInTarget, fixedState("", errors.New("forbidden")))
+
+	// the timeout elapses right after the first poll
+	err := waitAll(context.Background(), []*waitEntry{unregistered, registered, pending, stillRegistering, stillUnregistering, failing}, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("waitAll() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		entry         *waitEntry
+		register      bool
+		expectedError bool
+	}{
+		{"unregistered is registered", unregistered, true, false},
+		{"registered is done", registered, false, false},
+		{"pending is done for RPs and features alike", pending, false, false},
+		{"still registering is re-triggered and failed", stillRegistering, true, true},
+		{"still unregistering is failed", stillUnregistering, false, true},
+		{"state error is failed", failing, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			register, err := tt.entry.outcome()
+			if register != tt.register || (err != nil) != tt.expectedError {
+				t.Errorf("outcome() = %v, %v, expected %v, error %v", register, err, tt.register, tt.expectedError)
+			}
+		})
+	}
+
+	if newWaitEntry(plan.ReasonNotFoundInTarget, fixedState("", nil)) != nil {
+		t.Errorf("newWaitEntry(NotFoundInTarget) expected no wait")
+	}
+}
+
+func TestWaitForInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reasons := []string{plan.ReasonStuckRegisteringInTarget, plan.ReasonNotFoundInTarget}
+	getState := func(i int) func(ctx context.Context) (string, error) {
+		return fixedState("Registering", nil)
+	}
+
+	waits, err := waitFor(ctx, "RPs", reasons, getState, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("waitFor() error = %v, expected context.Canceled", err)
+	}
+	if waits != nil {
+		t.Errorf("waitFor() = %v, expected no entries to register once interrupted", waits)
+	}
+}
@@ -0,0 +1,173 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/featurename"
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+	"github.com/gerrytan/azsubsyn/internal/plan"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+
+	AssertRegistered    = "registered"
+	AssertNotRegistered = "notRegistered"
+)
+
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule asserts the registration of a list of RPs and preview features. Entries are matched case-insensitively and may
+// be glob patterns, eg: "Microsoft.ContainerService/AKS-*".
+type Rule struct {
+	Name              string   `json:"name"`
+	Severity          string   `json:"severity"` // error (default) | warning | info
+	Assert            string   `json:"assert"`   // registered | notRegistered
+	ResourceProviders []string `json:"resourceProviders"`
+	PreviewFeatures   []string `json:"previewFeatures"` // Namespace/Key format
+}
+
+// Result of evaluating one rule, each violation describes an entry breaking the assertion.
+type Result struct {
+	Rule       Rule
+	Violations []string
+}
+
+func (r *Result) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file %s: %w", path, err)
+	}
+
+	var rules Rules
+	if err := json.Unmarshal(jsonutil.StripJSONComments(data), &rules); err != nil {
+		return nil, fmt.Errorf("failed to deserialize rules from %s: %w", path, err)
+	}
+
+	for i := range rules.Rules {
+		if err := rules.Rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("bad rule #%d in %s: %w", i+1, path, err)
+		}
+	}
+
+	return &rules, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityError
+	case SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("rule %q has unknown severity %q, expected %s, %s or %s", r.Name, r.Severity, SeverityError, SeverityWarning, SeverityInfo)
+	}
+
+	if r.Assert != AssertRegistered && r.Assert != AssertNotRegistered {
+		return fmt.Errorf("rule %q has unknown assert %q, expected %s or %s", r.Name, r.Assert, AssertRegistered, AssertNotRegistered)
+	}
+
+	for _, pattern := range append(append([]string{}, r.ResourceProviders...), r.PreviewFeatures...) {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return fmt.Errorf("rule %q has bad pattern %q: %w", r.Name, pattern, err)
+		}
+	}
+	for _, name := range r.PreviewFeatures {
+		if _, _, err := featurename.Split(name); err != nil {
+			return fmt.Errorf("rule %q has bad preview feature %q: %w", r.Name, name, err)
+		}
+	}
+
+	return nil
+}
+
+// entry is a RP or preview feature of the state under check, name is the RP namespace or the feature Namespace/Key.
+type entry struct {
+	name  string
+	state string
+}
+
+func evaluate(rules *Rules, state *plan.SubscriptionState) []Result {
+	var rpEntries, featureEntries []entry
+	for _, rp := range state.ResourceProviders {
+		rpEntries = append(rpEntries, entry{name: pointer.From(rp.Namespace), state: pointer.From(rp.RegistrationState)})
+	}
+	for _, feature := range state.PreviewFeatures {
+		var featureState string
+		if feature.Properties != nil {
+			featureState = pointer.From(feature.Properties.State)
+		}
+		featureEntries = append(featureEntries, entry{name: pointer.From(feature.Name), state: featureState})
+	}
+
+	var results []Result
+	for _, rule := range rules.Rules {
+		result := Result{Rule: rule}
+		result.Violations = append(result.Violations, evaluatePatterns(rule.Assert, rule.ResourceProviders, rpEntries)...)
+		result.Violations = append(result.Violations, evaluatePatterns(rule.Assert, rule.PreviewFeatures, featureEntries)...)
+		results = append(results, result)
+	}
+	return results
+}
+
+// evaluatePatterns requires every pattern to match at least one registered entry for the registered assertion, any one
+// is enough so "Microsoft.Compute/*" passes with a single registered feature. For the notRegistered assertion no pattern
+// may match an entry that is registered or on its way to be.
+func evaluatePatterns(assert string, patterns []string, entries []entry) (violations []string) {
+	for _, pattern := range patterns {
+		var matches []entry
+		for _, e := range entries {
+			if plan.GlobMatch(pattern, e.name) {
+				matches = append(matches, e)
+			}
+		}
+
+		switch assert {
+		case AssertRegistered:
+			if len(matches) == 0 {
+				violations = append(violations, fmt.Sprintf("%s: not found", pattern))
+				continue
+			}
+			registered := false
+			for _, m := range matches {
+				if plan.ParseRegistrationState(m.state) == plan.StateRegistered {
+					registered = true
+					break
+				}
+			}
+			if !registered {
+				violations = append(violations, fmt.Sprintf("%s: %s", matches[0].name, stateOrUnknown(matches[0].state)))
+			}
+		case AssertNotRegistered:
+			for _, m := range matches {
+				if state := plan.ParseRegistrationState(m.state); state.IsRegisteredOrPending() || state == plan.StateRegistering {
+					violations = append(violations, fmt.Sprintf("%s: %s", m.name, m.state))
+				}
+			}
+		}
+	}
+	return
+}
+
+func stateOrUnknown(state string) string {
+	if state == "" {
+		return "Unknown"
+	}
+	return state
+}
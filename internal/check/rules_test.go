@@ -0,0 +1,98 @@
+package check
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/plan"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+func TestEvaluate(t *testing.T) {
+	state := &plan.SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			{Namespace: pointer.To("Microsoft.ContainerService"), RegistrationState: pointer.To("Registered")},
+			{Namespace: pointer.To("Microsoft.KeyVault"), RegistrationState: pointer.To("NotRegistered")},
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			{Name: pointer.To("Microsoft.ContainerService/AKS-KedaPreview"), Properties: &armfeatures.FeatureProperties{State: pointer.To("Registered")}},
+			{Name: pointer.To("Microsoft.Compute/EncryptionAtHost"), Properties: &armfeatures.FeatureProperties{State: pointer.To("Pending")}},
+			{Name: pointer.To("Microsoft.Network/AllowX"), Properties: &armfeatures.FeatureProperties{State: pointer.To("Unregistered")}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		expected []string
+	}{
+		{
+			name:     "Registered RPs",
+			rule:     Rule{Assert: AssertRegistered, ResourceProviders: []string{"microsoft.containerservice"}},
+			expected: nil,
+		},
+		{
+			name:     "RP not registered or not found",
+			rule:     Rule{Assert: AssertRegistered, ResourceProviders: []string{"Microsoft.KeyVault", "Microsoft.Cache"}},
+			expected: []string{"Microsoft.KeyVault: NotRegistered", "Microsoft.Cache: not found"},
+		},
+		{
+			name:     "Registered feature glob",
+			rule:     Rule{Assert: AssertRegistered, PreviewFeatures: []string{"Microsoft.ContainerService/AKS-*"}},
+			expected: nil,
+		},
+		{
+			name:     "Pending feature is not registered",
+			rule:     Rule{Assert: AssertRegistered, PreviewFeatures: []string{"Microsoft.Compute/EncryptionAtHost"}},
+			expected: []string{"Microsoft.Compute/EncryptionAtHost: Pending"},
+		},
+		{
+			name:     "Not registered",
+			rule:     Rule{Assert: AssertNotRegistered, ResourceProviders: []string{"Microsoft.KeyVault"}, PreviewFeatures: []string{"Microsoft.Network/*", "Microsoft.Foo/Bar"}},
+			expected: nil,
+		},
+		{
+			name:     "Registered and pending features must not be registered",
+			rule:     Rule{Assert: AssertNotRegistered, PreviewFeatures: []string{"Microsoft.*/*"}},
+			expected: []string{"Microsoft.ContainerService/AKS-KedaPreview: Registered", "Microsoft.Compute/EncryptionAtHost: Pending"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := evaluate(&Rules{Rules: []Rule{tt.rule}}, state)
+			if !reflect.DeepEqual(results[0].Violations, tt.expected) {
+				t.Errorf("violations = %v, expected %v", results[0].Violations, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{name: "Defaults to error severity", rule: Rule{Name: "a", Assert: AssertRegistered}},
+		{name: "Missing name", rule: Rule{Assert: AssertRegistered}, wantErr: true},
+		{name: "Unknown severity", rule: Rule{Name: "a", Severity: "fatal", Assert: AssertRegistered}, wantErr: true},
+		{name: "Unknown assert", rule: Rule{Name: "a", Assert: "present"}, wantErr: true},
+		{name: "Bad feature name", rule: Rule{Name: "a", Assert: AssertRegistered, PreviewFeatures: []string{"AllowX"}}, wantErr: true},
+		{name: "Bad pattern", rule: Rule{Name: "a", Assert: AssertRegistered, ResourceProviders: []string{"Microsoft.["}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.rule.Severity != SeverityError {
+				t.Errorf("severity = %q, expected %q", tt.rule.Severity, SeverityError)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/plan"
+)
+
+func RunCheck() error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	fs.Usage = printUsage
+
+	var rulesPath, side string
+	var stateSource plan.StateSource
+	fs.StringVar(&rulesPath, "rules", "", "")
+	fs.StringVar(&side, "side", "target", "")
+	stateSource.RegisterFlags(fs, "")
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+
+	if rulesPath == "" || (side != "src" && side != "target") || fs.NArg() > 0 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	if err := stateSource.Validate(false); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+
+	rules, err := LoadRules(rulesPath)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to load rules: %w", err)
+	}
+
+	state, err := resolveState(context.Background(), &stateSource, side)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to get subscription state: %w", err)
+	}
+
+	fmt.Printf("🔎 Checking %d rules against subscription %s (%s)...\n", len(rules.Rules), state.SubscriptionID, state.Origin)
+	results := evaluate(rules, state)
+
+	failures := make(map[string]int)
+	for _, result := range results {
+		if result.Passed() {
+			fmt.Printf("✅ PASS [%s] %s\n", result.Rule.Severity, result.Rule.Name)
+			continue
+		}
+
+		failures[result.Rule.Severity]++
+		fmt.Printf("%s FAIL [%s] %s\n", severityIcon(result.Rule.Severity), result.Rule.Severity, result.Rule.Name)
+		for _, violation := range result.Violations {
+			fmt.Printf("  - %s\n", violation)
+		}
+	}
+
+	failed := failures[SeverityError] + failures[SeverityWarning] + failures[SeverityInfo]
+	fmt.Println()
+	fmt.Println("📊 Check summary:")
+	fmt.Printf("  - Passed: %d\n", len(results)-failed)
+	fmt.Printf("  - Failed: %d (%d error, %d warning, %d info)\n", failed, failures[SeverityError], failures[SeverityWarning], failures[SeverityInfo])
+
+	if failures[SeverityError] > 0 {
+		return fmt.Errorf("❌ %d rules with error severity failed", failures[SeverityError])
+	}
+
+	fmt.Println("✅ No error severity violations found")
+	return nil
+}
+
+// resolveState reads the state from the given files, or only the registrations of the live subscription since rules
+// never look at the preview feature metadata.
+func resolveState(ctx context.Context, stateSource *plan.StateSource, side string) (*plan.SubscriptionState, error) {
+	if stateSource.IsSet() {
+		states, err := stateSource.Resolve(ctx, side, side)
+		if err != nil {
+			return nil, err
+		}
+		return states[0], nil
+	}
+
+	config, err := config.BuildConfig(side)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s configuration: %w", side, err)
+	}
+	return plan.FetchRegistrations(ctx, config, side)
+}
+
+func severityIcon(severity string) string {
+	switch severity {
+	case SeverityWarning:
+		return "⚠️"
+	case SeverityInfo:
+		return "ℹ️"
+	default:
+		return "❌"
+	}
+}
+
+func printUsage() {
+	fmt.Println("azsubsyn check - Assert RP and preview feature registrations of a subscription against rules")
+	fmt.Println()
+	fmt.Println("USAGE:")
+	fmt.Println("  azsubsyn check --rules <rules-file> [options]")
+	fmt.Println()
+	fmt.Println("OPTIONS:")
+	fmt.Println("  --rules          JSONC file listing the rules to evaluate")
+	fmt.Println("  --side           Live subscription to check, src or target (default: target)")
+	fmt.Println("  --snapshot       Check a snapshot file created by 'azsubsyn snapshot' instead of the live subscription")
+	fmt.Println("  --az-providers   Check 'az provider list -o json' output instead of the live subscription,")
+	fmt.Println("                   requires --az-features")
+	fmt.Println("  --az-features    'az feature list -o json' output, requires --az-providers")
+	fmt.Println()
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  Prints pass / fail for each rule along with its severity. Exits with a non-zero code when any rule")
+	fmt.Println("  with error severity fails, warning and info failures are only reported.")
+	fmt.Println()
+	fmt.Println("  Entries are case-insensitive glob patterns. A registered pattern passes when ANY matching RP / preview")
+	fmt.Println("  feature is Registered, eg: Microsoft.Compute/* passes with a single registered Compute feature, list exact")
+	fmt.Println("  names to require each of them. A notRegistered pattern fails when any match is registered or pending.")
+}
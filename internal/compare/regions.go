@@ -0,0 +1,75 @@
+package compare
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// RegionGap lists the resource types the source can deploy in a region and the target can't.
+type RegionGap struct {
+	Region        string   `json:"region"`            // normalized, eg: "westeurope"
+	Unknown       bool     `json:"unknown,omitempty"` // no resource type of the source is offered in the region, likely a typo
+	ResourceTypes []string `json:"resourceTypes"`     // eg: "Microsoft.Web/sites"
+}
+
+// normalizeLocation turns the display names returned by ARM into region names, eg: "West Europe" -> "westeurope".
+func normalizeLocation(location string) string {
+	return strings.ToLower(strings.ReplaceAll(location, " ", ""))
+}
+
+// resourceTypeLocations returns the normalized locations of every resource type keyed by lower-cased
+// "Namespace/type", along with the canonical name of each type. Types without locations aren't regional and are left out.
+func resourceTypeLocations(rps []*armresources.Provider) (locations map[string]map[string]bool, names map[string]string) {
+	locations = make(map[string]map[string]bool)
+	names = make(map[string]string)
+
+	for _, rp := range rps {
+		namespace := pointer.From(rp.Namespace)
+		for _, resourceType := range rp.ResourceTypes {
+			if len(resourceType.Locations) == 0 {
+				continue
+			}
+
+			name := namespace + "/" + pointer.From(resourceType.ResourceType)
+			key := strings.ToLower(name)
+			names[key] = name
+			if locations[key] == nil {
+				locations[key] = make(map[string]bool)
+			}
+			for _, location := range resourceType.Locations {
+				locations[key][normalizeLocation(pointer.From(location))] = true
+			}
+		}
+	}
+
+	return
+}
+
+// compareRegions returns, for each region in the given order, the resource types offered to the source in that region
+// but not to the target. A region no source resource type is offered in is marked unknown rather than reported as
+// having no gap.
+func compareRegions(srcRPs []*armresources.Provider, targetRPs []*armresources.Provider, regions []string) []RegionGap {
+	srcLocations, names := resourceTypeLocations(srcRPs)
+	targetLocations, _ := resourceTypeLocations(targetRPs)
+
+	var gaps []RegionGap
+	for _, region := range regions {
+		gap := RegionGap{Region: normalizeLocation(region), Unknown: true, ResourceTypes: []string{}}
+		for key, locations := range srcLocations {
+			if !locations[gap.Region] {
+				continue
+			}
+			gap.Unknown = false
+			if !targetLocations[key][gap.Region] {
+				gap.ResourceTypes = append(gap.ResourceTypes, names[key])
+			}
+		}
+		sort.Strings(gap.ResourceTypes)
+		gaps = append(gaps, gap)
+	}
+
+	return gaps
+}
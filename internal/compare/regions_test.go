@@ -0,0 +1,54 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+func resourceType(name string, locations ...string) *armresources.ProviderResourceType {
+	resourceType := &armresources.ProviderResourceType{ResourceType: pointer.To(name)}
+	for _, location := range locations {
+		resourceType.Locations = append(resourceType.Locations, pointer.To(location))
+	}
+	return resourceType
+}
+
+func TestCompareRegions(t *testing.T) {
+	srcRPs := []*armresources.Provider{
+		{
+			Namespace: pointer.To("Microsoft.Web"),
+			ResourceTypes: []*armresources.ProviderResourceType{
+				resourceType("sites", "West Europe", "North Europe"),
+				resourceType("staticSites", "West Europe"),
+				resourceType("operations"),
+			},
+		},
+		{
+			Namespace:     pointer.To("Microsoft.Cache"),
+			ResourceTypes: []*armresources.ProviderResourceType{resourceType("redis", "westeurope")},
+		},
+	}
+	targetRPs := []*armresources.Provider{
+		{
+			Namespace: pointer.To("microsoft.web"),
+			ResourceTypes: []*armresources.ProviderResourceType{
+				resourceType("sites", "North Europe"),
+				resourceType("StaticSites", "West Europe"),
+			},
+		},
+	}
+
+	expected := []RegionGap{
+		{Region: "westeurope", ResourceTypes: []string{"Microsoft.Cache/redis", "Microsoft.Web/sites"}},
+		{Region: "northeurope", ResourceTypes: []string{}},
+		{Region: "westeurop", Unknown: true, ResourceTypes: []string{}},
+	}
+
+	actual := compareRegions(srcRPs, targetRPs, []string{"West Europe", "northeurope", "westeurop"})
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("compareRegions() = %+v, expected %+v", actual, expected)
+	}
+}
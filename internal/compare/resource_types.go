@@ -0,0 +1,150 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/credential"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// ResourceTypeDiff describes how a resource type offered to the source differs in the target, offer restrictions and
+// feature flag gating usually show up as missing API versions or capabilities.
+type ResourceTypeDiff struct {
+	ResourceType        string   `json:"resourceType"`                  // eg: "Microsoft.Web/sites"
+	MissingInTarget     bool     `json:"missingInTarget,omitempty"`     // the namespace is in target but not the type
+	MissingAPIVersions  []string `json:"missingApiVersions,omitempty"`  // in source but not in target
+	ExtraAPIVersions    []string `json:"extraApiVersions,omitempty"`    // in target but not in source
+	MissingCapabilities []string `json:"missingCapabilities,omitempty"` // eg: "CrossSubscriptionResourceMove"
+	ExtraCapabilities   []string `json:"extraCapabilities,omitempty"`
+}
+
+// compareResourceTypes returns the resource types of namespaces present on both sides whose API versions or
+// capabilities differ, sorted by type. Namespaces missing from the target are left to `azsubsyn plan`.
+func compareResourceTypes(srcRPs []*armresources.Provider, targetRPs []*armresources.Provider) (diffs []ResourceTypeDiff) {
+	srcTypes := resourceTypesByName(srcRPs)
+	targetTypes := resourceTypesByName(targetRPs)
+
+	targetNamespaces := make(map[string]bool)
+	for _, rp := range targetRPs {
+		targetNamespaces[strings.ToLower(pointer.From(rp.Namespace))] = true
+	}
+
+	for key, src := range srcTypes {
+		namespace, _, _ := strings.Cut(key, "/")
+		if !targetNamespaces[namespace] {
+			continue
+		}
+
+		diff := ResourceTypeDiff{ResourceType: src.name}
+		target, exists := targetTypes[key]
+		if !exists {
+			diff.MissingInTarget = true
+			diffs = append(diffs, diff)
+			continue
+		}
+
+		diff.MissingAPIVersions, diff.ExtraAPIVersions = setDifference(src.apiVersions, target.apiVersions)
+		diff.MissingCapabilities, diff.ExtraCapabilities = setDifference(src.capabilities, target.capabilities)
+		if len(diff.MissingAPIVersions)+len(diff.ExtraAPIVersions)+len(diff.MissingCapabilities)+len(diff.ExtraCapabilities) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return strings.ToLower(diffs[i].ResourceType) < strings.ToLower(diffs[j].ResourceType)
+	})
+	return
+}
+
+type resourceTypeInfo struct {
+	name         string
+	apiVersions  []string
+	capabilities []string
+}
+
+// resourceTypesByName keys the resource types by lower-cased "Namespace/type".
+func resourceTypesByName(rps []*armresources.Provider) map[string]resourceTypeInfo {
+	types := make(map[string]resourceTypeInfo)
+	for _, rp := range rps {
+		namespace := pointer.From(rp.Namespace)
+		for _, resourceType := range rp.ResourceTypes {
+			info := resourceTypeInfo{name: namespace + "/" + pointer.From(resourceType.ResourceType)}
+			for _, apiVersion := range resourceType.APIVersions {
+				info.apiVersions = append(info.apiVersions, pointer.From(apiVersion))
+			}
+			// eg: "CrossResourceGroupResourceMove, CrossSubscriptionResourceMove, SupportsTags, SupportsLocation"
+			for _, capability := range strings.Split(pointer.From(resourceType.Capabilities), ",") {
+				if capability = strings.TrimSpace(capability); capability != "" {
+					info.capabilities = append(info.capabilities, capability)
+				}
+			}
+			types[strings.ToLower(info.name)] = info
+		}
+	}
+	return types
+}
+
+// setDifference returns the sorted values only in a and only in b, compared case-insensitively.
+func setDifference(a []string, b []string) (onlyInA []string, onlyInB []string) {
+	inA := make(map[string]bool)
+	for _, value := range a {
+		inA[strings.ToLower(value)] = true
+	}
+	inB := make(map[string]bool)
+	for _, value := range b {
+		inB[strings.ToLower(value)] = true
+	}
+
+	for _, value := range a {
+		if !inB[strings.ToLower(value)] {
+			onlyInA = append(onlyInA, value)
+		}
+	}
+	for _, value := range b {
+		if !inA[strings.ToLower(value)] {
+			onlyInB = append(onlyInB, value)
+		}
+	}
+	sort.Strings(onlyInA)
+	sort.Strings(onlyInB)
+	return
+}
+
+// refetchResourceTypes replaces the resource types of the given namespaces with the ones returned by the provider
+// resource types API, which reports them for the subscription rather than as part of the whole provider list.
+func refetchResourceTypes(ctx context.Context, config *config.Config, rps []*armresources.Provider, namespaces map[string]bool) ([]*armresources.Provider, error) {
+	cred, err := credential.BuildCredential(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	client, err := armresources.NewProviderResourceTypesClient(config.SubscriptionID, cred, credential.ClientOptions(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider resource types client: %w", err)
+	}
+
+	refetched := make([]*armresources.Provider, 0, len(rps))
+	for _, rp := range rps {
+		namespace := pointer.From(rp.Namespace)
+		if !namespaces[strings.ToLower(namespace)] {
+			refetched = append(refetched, rp)
+			continue
+		}
+
+		resp, err := client.List(ctx, namespace, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource types of %s: %w", namespace, err)
+		}
+
+		copied := *rp
+		copied.ResourceTypes = resp.Value
+		refetched = append(refetched, &copied)
+	}
+
+	return refetched, nil
+}
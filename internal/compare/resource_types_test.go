@@ -0,0 +1,58 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+func versionedType(name string, capabilities string, apiVersions ...string) *armresources.ProviderResourceType {
+	resourceType := &armresources.ProviderResourceType{ResourceType: pointer.To(name), Capabilities: pointer.To(capabilities)}
+	for _, apiVersion := range apiVersions {
+		resourceType.APIVersions = append(resourceType.APIVersions, pointer.To(apiVersion))
+	}
+	return resourceType
+}
+
+func TestCompareResourceTypes(t *testing.T) {
+	srcRPs := []*armresources.Provider{
+		{
+			Namespace: pointer.To("Microsoft.Web"),
+			ResourceTypes: []*armresources.ProviderResourceType{
+				versionedType("sites", "SupportsTags, SupportsLocation, CrossSubscriptionResourceMove", "2024-04-01", "2023-12-01"),
+				versionedType("staticSites", "SupportsTags", "2024-04-01"),
+				versionedType("kubeEnvironments", "SupportsTags", "2024-04-01"),
+			},
+		},
+		{
+			Namespace:     pointer.To("Microsoft.Cache"),
+			ResourceTypes: []*armresources.ProviderResourceType{versionedType("redis", "SupportsTags", "2024-03-01")},
+		},
+	}
+	targetRPs := []*armresources.Provider{
+		{
+			Namespace: pointer.To("Microsoft.Web"),
+			ResourceTypes: []*armresources.ProviderResourceType{
+				versionedType("sites", "SupportsTags,SupportsLocation", "2023-12-01", "2022-03-01"),
+				versionedType("STATICSITES", "SupportsTags", "2024-04-01"),
+			},
+		},
+	}
+
+	expected := []ResourceTypeDiff{
+		{ResourceType: "Microsoft.Web/kubeEnvironments", MissingInTarget: true},
+		{
+			ResourceType:        "Microsoft.Web/sites",
+			MissingAPIVersions:  []string{"2024-04-01"},
+			ExtraAPIVersions:    []string{"2022-03-01"},
+			MissingCapabilities: []string{"CrossSubscriptionResourceMove"},
+		},
+	}
+
+	actual := compareResourceTypes(srcRPs, targetRPs)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("compareResourceTypes() = %+v, expected %+v", actual, expected)
+	}
+}
@@ -0,0 +1,242 @@
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/flagutil"
+	"github.com/gerrytan/azsubsyn/internal/plan"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// Report is the result of `azsubsyn compare`, informational only.
+type Report struct {
+	Source        string             `json:"source"` // eg: "12345678-1234-1234-1234-123456789abc (live)"
+	Target        string             `json:"target"`
+	Regions       []RegionGap        `json:"regions,omitempty"`
+	ResourceTypes []ResourceTypeDiff `json:"resourceTypes,omitempty"`
+}
+
+func RunCompare() error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	fs.Usage = printUsage
+
+	var srcSource, targetSource plan.StateSource
+	var regions flagutil.StringList
+	var output string
+	var resourceTypes, viaResourceTypesAPI bool
+	srcSource.RegisterFlags(fs, "source")
+	targetSource.RegisterFlags(fs, "target")
+	fs.Var(&regions, "regions", "")
+	fs.BoolVar(&resourceTypes, "resource-types", false, "")
+	fs.BoolVar(&viaResourceTypesAPI, "via-resource-types-api", false, "")
+	fs.StringVar(&output, "o", "", "")
+	fs.StringVar(&output, "output", "", "")
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+
+	regionList := splitList(regions)
+	if (len(regionList) == 0 && !resourceTypes) || fs.NArg() > 0 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	if err := srcSource.Validate(false); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+	if err := targetSource.Validate(false); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+
+	ctx := context.Background()
+
+	srcStates, err := srcSource.Resolve(ctx, "src", "source")
+	if err != nil {
+		return fmt.Errorf("❌ Failed to get source subscription state: %w", err)
+	}
+	targetStates, err := targetSource.Resolve(ctx, "target", "target")
+	if err != nil {
+		return fmt.Errorf("❌ Failed to get target subscription state: %w", err)
+	}
+	srcState, targetState := srcStates[0], targetStates[0]
+
+	report := &Report{
+		Source: fmt.Sprintf("%s (%s)", srcState.SubscriptionID, srcState.Origin),
+		Target: fmt.Sprintf("%s (%s)", targetState.SubscriptionID, targetState.Origin),
+	}
+
+	if len(regionList) > 0 {
+		fmt.Printf("🌍 Comparing resource type availability in %d regions...\n", len(regionList))
+		report.Regions = compareRegions(srcState.ResourceProviders, targetState.ResourceProviders, regionList)
+		printRegionGaps(report.Regions)
+	}
+
+	if resourceTypes {
+		srcRPs, targetRPs := srcState.ResourceProviders, targetState.ResourceProviders
+		if viaResourceTypesAPI {
+			namespaces := sharedNamespaces(srcRPs, targetRPs)
+			if srcRPs, err = refetchLiveResourceTypes(ctx, srcState, "src", srcRPs, namespaces); err != nil {
+				return fmt.Errorf("❌ Failed to get source resource types: %w", err)
+			}
+			if targetRPs, err = refetchLiveResourceTypes(ctx, targetState, "target", targetRPs, namespaces); err != nil {
+				return fmt.Errorf("❌ Failed to get target resource types: %w", err)
+			}
+		}
+
+		fmt.Println("🧬 Comparing API versions and capabilities of resource types...")
+		report.ResourceTypes = compareResourceTypes(srcRPs, targetRPs)
+		printResourceTypeDiffs(report.ResourceTypes)
+	}
+
+	if output != "" {
+		if err := report.Save(output); err != nil {
+			return fmt.Errorf("❌ Failed to save report: %w", err)
+		}
+		fmt.Printf("✅ Report written successfully to %s\n", output)
+	}
+	return nil
+}
+
+// refetchLiveResourceTypes fetches the resource types through the provider resource types API for a live side, file
+// based sides are returned as is.
+func refetchLiveResourceTypes(ctx context.Context, state *plan.SubscriptionState, side string, rps []*armresources.Provider, namespaces map[string]bool) ([]*armresources.Provider, error) {
+	if state.Origin != "live" {
+		return rps, nil
+	}
+
+	sideConfig, err := config.BuildConfig(side)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("🔍 Fetching resource types of %d namespaces from %s subscription...\n", len(namespaces), side)
+	return refetchResourceTypes(ctx, sideConfig, rps, namespaces)
+}
+
+func sharedNamespaces(srcRPs []*armresources.Provider, targetRPs []*armresources.Provider) map[string]bool {
+	inTarget := make(map[string]bool)
+	for _, rp := range targetRPs {
+		inTarget[strings.ToLower(pointer.From(rp.Namespace))] = true
+	}
+
+	shared := make(map[string]bool)
+	for _, rp := range srcRPs {
+		if namespace := strings.ToLower(pointer.From(rp.Namespace)); inTarget[namespace] {
+			shared[namespace] = true
+		}
+	}
+	return shared
+}
+
+func printResourceTypeDiffs(diffs []ResourceTypeDiff) {
+	if len(diffs) == 0 {
+		fmt.Println("  - ✅ Resource types offer the same API versions and capabilities")
+		return
+	}
+
+	fmt.Printf("  - ⚠️  %d resource types differ\n", len(diffs))
+	for _, diff := range diffs {
+		if diff.MissingInTarget {
+			fmt.Printf("    - %s: not offered to the target\n", diff.ResourceType)
+			continue
+		}
+		fmt.Printf("    - %s\n", diff.ResourceType)
+		printValues("missing API versions", diff.MissingAPIVersions)
+		printValues("extra API versions", diff.ExtraAPIVersions)
+		printValues("missing capabilities", diff.MissingCapabilities)
+		printValues("extra capabilities", diff.ExtraCapabilities)
+	}
+}
+
+func printValues(label string, values []string) {
+	if len(values) > 0 {
+		fmt.Printf("      %s: %s\n", label, strings.Join(values, ", "))
+	}
+}
+
+func printRegionGaps(gaps []RegionGap) {
+	for _, gap := range gaps {
+		if gap.Unknown {
+			fmt.Printf("  - ⚠️  %s: no resource type is offered to the source in this region, check the region name\n", gap.Region)
+			continue
+		}
+		if len(gap.ResourceTypes) == 0 {
+			fmt.Printf("  - ✅ %s: every resource type of the source is offered to the target\n", gap.Region)
+			continue
+		}
+		fmt.Printf("  - ⚠️  %s: %d resource types offered to the source but not to the target\n", gap.Region, len(gap.ResourceTypes))
+		for _, resourceType := range gap.ResourceTypes {
+			fmt.Printf("    - %s\n", resourceType)
+		}
+	}
+}
+
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize report to JSON: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// splitList flattens repeated and comma separated flag values, eg: "--regions westeurope,northeurope --regions eastus".
+func splitList(values []string) (items []string) {
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return
+}
+
+func printUsage() {
+	fmt.Println("azsubsyn compare - Report resource type differences between source and target subscriptions")
+	fmt.Println()
+	fmt.Println("USAGE:")
+	fmt.Println("  azsubsyn compare [--regions <region>[,<region>...]] [--resource-types] [<source options>] [<target options>] [-o <report-file>]")
+	fmt.Println()
+	fmt.Println("OPTIONS:")
+	fmt.Println("  --regions <regions>             Regions to compare, comma separated or repeated, eg: westeurope,northeurope")
+	fmt.Println("  --resource-types                Report resource types whose API versions or capabilities differ")
+	fmt.Println("  --via-resource-types-api        With --resource-types, fetch the resource types of live sides per namespace through the")
+	fmt.Println("                                  provider resource types API instead of the provider list")
+	fmt.Println("  -o, --output <file>             Also write the report to a JSON file")
+	fmt.Println()
+	fmt.Println("SOURCE / TARGET OPTIONS:")
+	fmt.Println("  --source-snapshot <file>        Read the source state from a file created by `azsubsyn snapshot`")
+	fmt.Println("  --source-az-providers <file>    Read the source RPs from `az provider list -o json` output, requires --source-az-features")
+	fmt.Println("  --source-az-features <file>     Read the source preview features from `az feature list -o json` output")
+	fmt.Println("  --target-snapshot <file>        Same for the target")
+	fmt.Println("  --target-az-providers <file>")
+	fmt.Println("  --target-az-features <file>")
+	fmt.Println()
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  Lists, for each region, the resource types the source subscription can deploy there and the target can't,")
+	fmt.Println("  eg: Microsoft.Web/sites in westeurope. Regions are matched by name, \"West Europe\" and westeurope are the same.")
+	fmt.Println("  A region none of the source resource types is offered in, eg: a typo such as westeurop, is reported as unknown.")
+	fmt.Println("  With --resource-types, resource types of namespaces present on both sides are reported when the target misses")
+	fmt.Println("  them, or when their API versions or capabilities differ. Offer restrictions and feature flag gating usually")
+	fmt.Println("  show up that way.")
+	fmt.Println()
+	fmt.Println("  Each side is read from the live subscription unless one of its options is given.")
+}
@@ -3,21 +3,15 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 func BuildConfigs() (srcConfig *Config, targetConfig *Config, err error) {
-	srcConfig = &Config{
-		ClientID:       os.Getenv("AZSUBSYN_SRC_CLIENT_ID"),
-		ClientSecret:   os.Getenv("AZSUBSYN_SRC_CLIENT_SECRET"),
-		TenantID:       os.Getenv("AZSUBSYN_SRC_TENANT_ID"),
-		SubscriptionID: os.Getenv("AZSUBSYN_SRC_SUBSCRIPTION_ID"),
+	if srcConfig, err = buildConfigFromEnv("SRC"); err != nil {
+		return nil, nil, err
 	}
-
-	targetConfig = &Config{
-		ClientID:       os.Getenv("AZSUBSYN_TARGET_CLIENT_ID"),
-		ClientSecret:   os.Getenv("AZSUBSYN_TARGET_CLIENT_SECRET"),
-		TenantID:       os.Getenv("AZSUBSYN_TARGET_TENANT_ID"),
-		SubscriptionID: os.Getenv("AZSUBSYN_TARGET_SUBSCRIPTION_ID"),
+	if targetConfig, err = buildConfigFromEnv("TARGET"); err != nil {
+		return nil, nil, err
 	}
 
 	missingEnvVars := []string{}
@@ -33,6 +27,73 @@ func BuildConfigs() (srcConfig *Config, targetConfig *Config, err error) {
 	return
 }
 
+// BuildConfig builds the configuration of a single side, "src" or "target", when the other side is not needed.
+func BuildConfig(side string) (*Config, error) {
+	name := strings.ToUpper(side)
+	if name != "SRC" && name != "TARGET" {
+		return nil, fmt.Errorf("unknown side %q, expected src or target", side)
+	}
+
+	config, err := buildConfigFromEnv(name)
+	if err != nil {
+		return nil, err
+	}
+
+	missingEnvVars := checkEnvVar(config, name)
+	if len(missingEnvVars) > 0 {
+		for _, varName := range missingEnvVars {
+			fmt.Printf("  - ❌ Missing var: %s\n", varName)
+		}
+		return nil, fmt.Errorf("Missing required environment variables")
+	}
+
+	return config, nil
+}
+
+// BuildPrincipalConfig builds the service principal configuration of a single side without requiring a subscription ID,
+// used when the subscriptions are discovered rather than configured.
+func BuildPrincipalConfig(side string) (*Config, error) {
+	name := strings.ToUpper(side)
+	if name != "SRC" && name != "TARGET" {
+		return nil, fmt.Errorf("unknown side %q, expected src or target", side)
+	}
+
+	config, err := buildConfigFromEnv(name)
+	if err != nil {
+		return nil, err
+	}
+
+	missingEnvVars := []string{}
+	for _, varName := range checkEnvVar(config, name) {
+		if varName != "AZSUBSYN_"+name+"_SUBSCRIPTION_ID" {
+			missingEnvVars = append(missingEnvVars, varName)
+		}
+	}
+	if len(missingEnvVars) > 0 {
+		for _, varName := range missingEnvVars {
+			fmt.Printf("  - ❌ Missing var: %s\n", varName)
+		}
+		return nil, fmt.Errorf("Missing required environment variables")
+	}
+
+	return config, nil
+}
+
+func buildConfigFromEnv(name string) (*Config, error) {
+	cloud, err := CloudFromEnv(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		ClientID:       os.Getenv("AZSUBSYN_" + name + "_CLIENT_ID"),
+		ClientSecret:   os.Getenv("AZSUBSYN_" + name + "_CLIENT_SECRET"),
+		TenantID:       os.Getenv("AZSUBSYN_" + name + "_TENANT_ID"),
+		SubscriptionID: os.Getenv("AZSUBSYN_" + name + "_SUBSCRIPTION_ID"),
+		Cloud:          cloud,
+	}, nil
+}
+
 func checkEnvVar(config *Config, name string) []string {
 	missing := []string{}
 
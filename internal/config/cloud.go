@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+const (
+	CloudPublic       = "public"
+	CloudUSGovernment = "usgovernment"
+	CloudChina        = "china"
+)
+
+// NormalizeCloud returns the canonical name of an Azure cloud, an empty name is Azure Public.
+func NormalizeCloud(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", CloudPublic, "azurecloud":
+		return CloudPublic, nil
+	case CloudUSGovernment, "azureusgovernment":
+		return CloudUSGovernment, nil
+	case CloudChina, "azurechinacloud":
+		return CloudChina, nil
+	default:
+		return "", fmt.Errorf("unknown cloud %q, expected %s, %s or %s", name, CloudPublic, CloudUSGovernment, CloudChina)
+	}
+}
+
+// CloudFromEnv returns the cloud of a side, "src" or "target", from the AZSUBSYN_<SIDE>_CLOUD environment variable.
+func CloudFromEnv(side string) (string, error) {
+	varName := "AZSUBSYN_" + strings.ToUpper(side) + "_CLOUD"
+	name, err := NormalizeCloud(os.Getenv(varName))
+	if err != nil {
+		return "", fmt.Errorf("bad %s: %w", varName, err)
+	}
+	return name, nil
+}
+
+// CloudConfiguration returns the authority and ARM endpoints of the cloud the subscription lives in.
+func (c *Config) CloudConfiguration() cloud.Configuration {
+	switch c.Cloud {
+	case CloudUSGovernment:
+		return cloud.AzureGovernment
+	case CloudChina:
+		return cloud.AzureChina
+	default:
+		return cloud.AzurePublic
+	}
+}
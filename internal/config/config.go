@@ -5,4 +5,5 @@ type Config struct {
 	ClientSecret   string
 	TenantID       string
 	SubscriptionID string
+	Cloud          string // public (default) | usgovernment | china
 }
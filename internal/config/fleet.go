@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+)
+
+// Fleet lists subscriptions that are planned and applied together, each with its own credentials. Targets are the
+// subscriptions to sync, sources are the reference subscriptions they are synced from.
+type Fleet struct {
+	Sources []FleetSubscription `json:"sources"`
+	Targets []FleetSubscription `json:"targets"`
+}
+
+type FleetSubscription struct {
+	Name               string `json:"name"` // optional label, eg: "lz-prod-01"
+	TenantID           string `json:"tenantId"`
+	SubscriptionID     string `json:"subscriptionId"`
+	ClientID           string `json:"clientId"`
+	ClientSecretEnvVar string `json:"clientSecretEnvVar"` // secrets are never stored in the file, eg: "LZ_PROD_01_CLIENT_SECRET"
+	Cloud              string `json:"cloud,omitempty"`    // public (default) | usgovernment | china
+}
+
+func LoadFleet(path string) (configs []*Config, err error) {
+	fleet, err := loadFleetFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildFleetConfigs(fleet.Targets, "targets", path)
+}
+
+func LoadFleetSources(path string) (configs []*Config, err error) {
+	fleet, err := loadFleetFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildFleetConfigs(fleet.Sources, "sources", path)
+}
+
+func loadFleetFile(path string) (*Fleet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet file %s: %w", path, err)
+	}
+
+	var fleet Fleet
+	if err := json.Unmarshal(jsonutil.StripJSONComments(data), &fleet); err != nil {
+		return nil, fmt.Errorf("failed to deserialize fleet from %s: %w", path, err)
+	}
+
+	return &fleet, nil
+}
+
+func buildFleetConfigs(subs []FleetSubscription, kind string, path string) (configs []*Config, err error) {
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("no %s found in fleet file %s", kind, path)
+	}
+
+	seen := make(map[string]bool)
+	missing := []string{}
+	for i, sub := range subs {
+		label := sub.Name
+		if label == "" {
+			label = fmt.Sprintf("%s #%d", kind, i)
+		}
+
+		cloud, err := NormalizeCloud(sub.Cloud)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s in fleet file %s: %w", label, path, err)
+		}
+
+		config := &Config{
+			ClientID:       sub.ClientID,
+			TenantID:       sub.TenantID,
+			SubscriptionID: sub.SubscriptionID,
+			Cloud:          cloud,
+		}
+		if sub.ClientSecretEnvVar != "" {
+			config.ClientSecret = os.Getenv(sub.ClientSecretEnvVar)
+		}
+
+		if config.ClientID == "" {
+			missing = append(missing, label+": clientId")
+		}
+		if sub.ClientSecretEnvVar == "" {
+			missing = append(missing, label+": clientSecretEnvVar")
+		} else if config.ClientSecret == "" {
+			missing = append(missing, label+": environment variable "+sub.ClientSecretEnvVar)
+		}
+		if config.TenantID == "" {
+			missing = append(missing, label+": tenantId")
+		}
+		if config.SubscriptionID == "" {
+			missing = append(missing, label+": subscriptionId")
+		} else if seen[config.SubscriptionID] {
+			return nil, fmt.Errorf("duplicate subscription %s in %s of fleet file %s", config.SubscriptionID, kind, path)
+		}
+		seen[config.SubscriptionID] = true
+
+		configs = append(configs, config)
+	}
+
+	if len(missing) > 0 {
+		for _, m := range missing {
+			fmt.Printf("  - ❌ Missing value: %s\n", m)
+		}
+		return nil, fmt.Errorf("Missing required fleet %s values in %s", kind, path)
+	}
+
+	return
+}
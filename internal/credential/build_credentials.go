@@ -2,6 +2,7 @@ package credential
 
 import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/gerrytan/azsubsyn/internal/config"
@@ -14,6 +15,7 @@ func BuildCredential(config *config.Config) (cred *azidentity.ClientSecretCreden
 		config.ClientSecret,
 		&azidentity.ClientSecretCredentialOptions{
 			ClientOptions: azcore.ClientOptions{
+				Cloud: config.CloudConfiguration(),
 				Retry: policy.RetryOptions{
 					MaxRetries: 3,
 				},
@@ -21,3 +23,12 @@ func BuildCredential(config *config.Config) (cred *azidentity.ClientSecretCreden
 		},
 	)
 }
+
+// ClientOptions points the ARM clients to the cloud the subscription lives in.
+func ClientOptions(config *config.Config) *arm.ClientOptions {
+	return &arm.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Cloud: config.CloudConfiguration(),
+		},
+	}
+}
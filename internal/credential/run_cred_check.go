@@ -46,7 +46,7 @@ func testSubscriptionAccess(ctx context.Context, config *config.Config, kind str
 		return fmt.Errorf("failed to build %s credential: %w", kind, err)
 	}
 
-	client, err := armsubscriptions.NewClient(cred, nil)
+	client, err := armsubscriptions.NewClient(cred, ClientOptions(config))
 	if err != nil {
 		return fmt.Errorf("failed to create subscriptions client for %s: %w", kind, err)
 	}
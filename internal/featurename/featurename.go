@@ -0,0 +1,21 @@
+package featurename
+
+import (
+	"errors"
+	"strings"
+)
+
+// Split splits a preview feature name, eg: "Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", a name without
+// a namespace or a key, blank ones included, is an error.
+func Split(name string) (key, namespace string, err error) {
+	if strings.TrimSpace(name) == "" {
+		return "", "", errors.New("missing feature name")
+	}
+
+	namespace, key, found := strings.Cut(name, "/")
+	if !found || strings.TrimSpace(namespace) == "" || strings.TrimSpace(key) == "" {
+		return "", "", errors.New("expected Namespace/Key format")
+	}
+
+	return key, namespace, nil
+}
@@ -0,0 +1,32 @@
+package featurename
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name              string
+		expectedKey       string
+		expectedNamespace string
+		expectedError     bool
+	}{
+		{"Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "AllowMultiplePeeringLinksBetweenVnets", "Microsoft.Network", false},
+		{"Microsoft.Web/Foo/Bar", "Foo/Bar", "Microsoft.Web", false},
+		{"", "", "", true},
+		{"EncryptionAtHost", "", "", true},
+		{"Microsoft.Network/", "", "", true},
+		{"Microsoft.Network/ ", "", "", true},
+		{" /Foo", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, namespace, err := Split(tt.name)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("Split(%q) error = %v, expected error %v", tt.name, err, tt.expectedError)
+			}
+			if key != tt.expectedKey || namespace != tt.expectedNamespace {
+				t.Errorf("Split(%q) = %q, %q, expected %q, %q", tt.name, key, namespace, tt.expectedKey, tt.expectedNamespace)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package flagutil
+
+import "strings"
+
+// StringList is a flag.Value collecting every occurrence of a repeatable flag, eg: "--tag env=dev --tag team=core".
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *StringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// Approval classes of preview feature entries, manual features stay pending after registration until Microsoft
+// approves them through a support request.
+const (
+	ApprovalAuto   = "Auto"
+	ApprovalManual = "Manual"
+)
+
+// classifyApproval sets the approval class of every registration entry. The approval type from the feature metadata
+// wins, without it a feature still pending in source is assumed to be waiting for approval.
+func classifyApproval(prFeats []PreviewFeature, srcFeatures []*armfeatures.FeatureResult) {
+	pendingInSource := make(map[string]bool)
+	for _, feat := range srcFeatures {
+		if ParseRegistrationState(getState(feat)) == StatePending {
+			pendingInSource[strings.ToLower(pointer.From(feat.Name))] = true
+		}
+	}
+
+	for i := range prFeats {
+		if prFeats[i].Action == "unregister" || prFeats[i].Reason == ReasonUnavailableInTargetCloud {
+			continue
+		}
+
+		approvalType := ""
+		if prFeats[i].FeatureMetadata != nil {
+			approvalType = prFeats[i].ApprovalType
+		}
+
+		switch {
+		case strings.EqualFold(approvalType, string(armfeatures.SubscriptionFeatureRegistrationApprovalTypeApprovalRequired)):
+			prFeats[i].Approval = ApprovalManual
+		case strings.EqualFold(approvalType, string(armfeatures.SubscriptionFeatureRegistrationApprovalTypeAutoApproval)):
+			prFeats[i].Approval = ApprovalAuto
+		case pendingInSource[strings.ToLower(prFeats[i].Namespace+"/"+prFeats[i].Key)]:
+			prFeats[i].Approval = ApprovalManual
+		default:
+			prFeats[i].Approval = ApprovalAuto
+		}
+	}
+}
+
+func printApprovalSummary(w io.Writer, plan *Plan) {
+	var manual []string
+	for _, feat := range plan.PreviewFeatures {
+		if feat.Approval == ApprovalManual {
+			manual = append(manual, feat.Namespace+"/"+feat.Key)
+		}
+	}
+	if len(manual) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "📝 %d preview features need Microsoft approval, apply writes a support request draft for each\n", len(manual))
+	for _, name := range manual {
+		fmt.Fprintf(w, "  - Preview feature %s\n", name)
+	}
+}
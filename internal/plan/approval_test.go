@@ -0,0 +1,35 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+)
+
+func TestClassifyApproval(t *testing.T) {
+	srcFeatures := []*armfeatures.FeatureResult{
+		feature("Microsoft.Compute/EncryptionAtHost", "Registered"),
+		feature("Microsoft.Network/AllowGatewayLoadBalancer", "Pending"),
+		feature("Microsoft.Storage/AllowNFSV3", "Pending"),
+		feature("Microsoft.Web/Foo", "Registered"),
+	}
+	prFeats := []PreviewFeature{
+		{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", Reason: "NotFoundInTarget"},
+		{Key: "AllowGatewayLoadBalancer", Namespace: "Microsoft.Network", Reason: "NotFoundInTarget"},
+		{Key: "AllowNFSV3", Namespace: "Microsoft.Storage", Reason: "NotFoundInTarget", FeatureMetadata: &FeatureMetadata{ApprovalType: "AutoApproval"}},
+		{Key: "Foo", Namespace: "Microsoft.Web", Reason: "NotFoundInTarget", FeatureMetadata: &FeatureMetadata{ApprovalType: "ApprovalRequired"}},
+		{Key: "Bar", Namespace: "Microsoft.Web", Action: "unregister", Reason: "NotRegisteredInSource"},
+	}
+
+	classifyApproval(prFeats, srcFeatures)
+
+	expected := []string{ApprovalAuto, ApprovalManual, ApprovalAuto, ApprovalManual, ""}
+	var actual []string
+	for _, feat := range prFeats {
+		actual = append(actual, feat.Approval)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("classifyApproval() = %v, expected %v", actual, expected)
+	}
+}
@@ -0,0 +1,112 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// loadAzCliState reads the JSON output of `az provider list -o json` and `az feature list -o json`. Both commands
+// return the same shape as the ARM APIs, entries that aren't valid JSON objects are reported and skipped. Entries with a
+// missing namespace or a malformed feature name are kept so the planner lists them in the plan's skipped records.
+func loadAzCliState(providersPath string, featuresPath string, kind string) (*SubscriptionState, error) {
+	fmt.Printf("📂 Loading %s subscription az CLI output from %s and %s...\n", kind, providersPath, featuresPath)
+
+	data, err := os.ReadFile(providersPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read az provider list output %s: %w", providersPath, err)
+	}
+
+	rps, skippedRPs, err := parseAzProviderList(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse az provider list output %s: %w", providersPath, err)
+	}
+
+	data, err = os.ReadFile(featuresPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read az feature list output %s: %w", featuresPath, err)
+	}
+
+	features, skippedFeatures, err := parseAzFeatureList(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse az feature list output %s: %w", featuresPath, err)
+	}
+
+	for _, skipped := range skippedRPs {
+		fmt.Printf("  - ⚠️  Skipped entry in %s: %s\n", providersPath, skipped)
+	}
+	for _, skipped := range skippedFeatures {
+		fmt.Printf("  - ⚠️  Skipped entry in %s: %s\n", featuresPath, skipped)
+	}
+
+	return &SubscriptionState{
+		Origin:            "az CLI output " + providersPath + ", " + featuresPath,
+		SubscriptionID:    subscriptionIDFromProviders(rps),
+		ResourceProviders: rps,
+		PreviewFeatures:   features,
+	}, nil
+}
+
+func parseAzProviderList(data []byte) (rps []*armresources.Provider, skipped []string, err error) {
+	entries, err := splitJSONArray(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, entry := range entries {
+		var rp armresources.Provider
+		if err := json.Unmarshal(entry, &rp); err != nil {
+			skipped = append(skipped, fmt.Sprintf("#%d: %s", i, err))
+			continue
+		}
+		rps = append(rps, &rp)
+	}
+
+	return
+}
+
+func parseAzFeatureList(data []byte) (features []*armfeatures.FeatureResult, skipped []string, err error) {
+	entries, err := splitJSONArray(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, entry := range entries {
+		var feat armfeatures.FeatureResult
+		if err := json.Unmarshal(entry, &feat); err != nil {
+			skipped = append(skipped, fmt.Sprintf("#%d: %s", i, err))
+			continue
+		}
+		features = append(features, &feat)
+	}
+
+	return
+}
+
+func splitJSONArray(data []byte) (entries []json.RawMessage, err error) {
+	// az CLI on Windows may write a UTF-8 byte order mark
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("expected a JSON array: %w", err)
+	}
+
+	return
+}
+
+// example ID: "/subscriptions/12345678-1234-1234-1234-123456789abc/providers/Microsoft.Cache"
+func subscriptionIDFromProviders(rps []*armresources.Provider) string {
+	for _, rp := range rps {
+		parts := strings.Split(pointer.From(rp.ID), "/")
+		if len(parts) > 2 && strings.EqualFold(parts[1], "subscriptions") {
+			return parts[2]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package plan
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+func TestParseAzProviderList(t *testing.T) {
+	data := []byte("\xef\xbb\xbf" + `[
+  {
+    "id": "/subscriptions/12345678-1234-1234-1234-123456789abc/providers/Microsoft.Cache",
+    "namespace": "Microsoft.Cache",
+    "registrationPolicy": "RegistrationRequired",
+    "registrationState": "Registered",
+    "resourceTypes": [{ "resourceType": "redis", "locations": ["West Europe"] }]
+  },
+  { "id": "/subscriptions/12345678-1234-1234-1234-123456789abc/providers/", "registrationState": "Registered" },
+  { "namespace": 42 }
+]`)
+
+	rps, skipped, err := parseAzProviderList(data)
+	if err != nil {
+		t.Fatalf("parseAzProviderList() error = %v", err)
+	}
+
+	if len(rps) != 2 || pointer.From(rps[0].Namespace) != "Microsoft.Cache" || len(rps[0].ResourceTypes) != 1 {
+		t.Errorf("parseAzProviderList() = %+v, expected Microsoft.Cache with its resource type and the entry without namespace", rps)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("parseAzProviderList() skipped = %v, expected the entry that isn't an RP", skipped)
+	}
+	if subID := subscriptionIDFromProviders(rps); subID != "12345678-1234-1234-1234-123456789abc" {
+		t.Errorf("subscriptionIDFromProviders() = %q", subID)
+	}
+}
+
+func TestParseAzFeatureList(t *testing.T) {
+	data := []byte(`[
+  {
+    "id": "/subscriptions/12345678-1234-1234-1234-123456789abc/providers/Microsoft.Features/providers/Microsoft.Network/features/AllowMultiplePeeringLinksBetweenVnets",
+    "name": "Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets",
+    "properties": { "state": "Registered" },
+    "type": "Microsoft.Features/providers/features"
+  },
+  { "name": "NoSlashFeature", "properties": { "state": "Registered" } },
+  { "name": 42 }
+]`)
+
+	features, skipped, err := parseAzFeatureList(data)
+	if err != nil {
+		t.Fatalf("parseAzFeatureList() error = %v", err)
+	}
+
+	var names []string
+	for _, feat := range features {
+		names = append(names, pointer.From(feat.Name))
+	}
+	if expected := []string{"Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "NoSlashFeature"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("parseAzFeatureList() names = %v, expected %v", names, expected)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("parseAzFeatureList() skipped = %v, expected the entry that isn't a feature", skipped)
+	}
+}
+
+func TestAzCliMalformedFeatureInSkippedRecords(t *testing.T) {
+	dir := t.TempDir()
+	providersPath := filepath.Join(dir, "providers.json")
+	featuresPath := filepath.Join(dir, "features.json")
+	writeFile(t, providersPath, `[{ "namespace": "Microsoft.Network", "registrationState": "Registered" }]`)
+	writeFile(t, featuresPath, `[
+  { "name": "Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "properties": { "state": "Registered" } },
+  { "name": "AllowMultiplePeeringLinksBetweenVnets", "properties": { "state": "Registered" } }
+]`)
+
+	srcState, err := loadAzCliState(providersPath, featuresPath, "source")
+	if err != nil {
+		t.Fatalf("loadAzCliState() error = %v", err)
+	}
+
+	plan := buildPlan(srcState, &SubscriptionState{}, &planOptions{mode: "additive"})
+
+	expected := []SkippedRecord{{Side: "source", Kind: "previewFeature", Name: "AllowMultiplePeeringLinksBetweenVnets", Problem: "expected Namespace/Key format"}}
+	if !reflect.DeepEqual(plan.SkippedRecords, expected) {
+		t.Errorf("SkippedRecords = %+v, expected %+v", plan.SkippedRecords, expected)
+	}
+}
+
+func TestParseAzFeatureListNotAnArray(t *testing.T) {
+	if _, _, err := parseAzFeatureList([]byte(`{"name": "Microsoft.Network/Foo"}`)); err == nil {
+		t.Error("parseAzFeatureList() expected error for non-array input")
+	}
+}
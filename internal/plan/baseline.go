@@ -0,0 +1,73 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/featurename"
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// Baseline is a declarative list of RPs and preview features that must be registered in the target subscription.
+type Baseline struct {
+	ResourceProviders []string `json:"resourceProviders"` // eg: "Microsoft.ContainerService"
+	PreviewFeatures   []string `json:"previewFeatures"`   // eg: "Microsoft.ContainerService/AKS-KedaPreview"
+}
+
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline file %s: %w", path, err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(jsonutil.StripJSONComments(data), &baseline); err != nil {
+		return nil, fmt.Errorf("failed to deserialize baseline from %s: %w", path, err)
+	}
+
+	for _, name := range baseline.PreviewFeatures {
+		if _, _, err := featurename.Split(name); err != nil {
+			return nil, fmt.Errorf("bad preview feature %q in %s: %w", name, path, err)
+		}
+	}
+
+	return &baseline, nil
+}
+
+// toState converts the baseline into a source state where every listed RP and preview feature is registered.
+func (b *Baseline) toState(origin string, requiredReason string) *SubscriptionState {
+	state := &SubscriptionState{
+		Origin:         origin,
+		RequiredReason: requiredReason,
+	}
+
+	for _, namespace := range b.ResourceProviders {
+		state.ResourceProviders = append(state.ResourceProviders, &armresources.Provider{
+			Namespace:         pointer.To(namespace),
+			RegistrationState: pointer.To(string(StateRegistered)),
+		})
+	}
+
+	for _, name := range b.PreviewFeatures {
+		state.PreviewFeatures = append(state.PreviewFeatures, &armfeatures.FeatureResult{
+			Name:       pointer.To(name),
+			Properties: &armfeatures.FeatureProperties{State: pointer.To(string(StateRegistered))},
+		})
+	}
+
+	return state
+}
+
+func loadBaselineState(path string) (*SubscriptionState, error) {
+	fmt.Printf("📂 Loading baseline from %s...\n", path)
+	baseline, err := LoadBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return baseline.toState("baseline "+path, ReasonRequiredByBaseline), nil
+}
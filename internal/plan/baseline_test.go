@@ -0,0 +1,136 @@
+package plan
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestLoadBaseline(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    *Baseline
+		expectError bool
+	}{
+		{
+			name: "JSONC with comments",
+			content: `{
+  // RPs every landing zone needs
+  "resourceProviders": ["Microsoft.ContainerService", "Microsoft.KeyVault"],
+  /* preview features
+     enabled for AKS */
+  "previewFeatures": ["Microsoft.ContainerService/AKS-KedaPreview"]
+}`,
+			expected: &Baseline{
+				ResourceProviders: []string{"Microsoft.ContainerService", "Microsoft.KeyVault"},
+				PreviewFeatures:   []string{"Microsoft.ContainerService/AKS-KedaPreview"},
+			},
+		},
+		{
+			name:     "RPs only",
+			content:  `{"resourceProviders": ["Microsoft.Storage"]}`,
+			expected: &Baseline{ResourceProviders: []string{"Microsoft.Storage"}},
+		},
+		{
+			name:        "preview feature without namespace",
+			content:     `{"previewFeatures": ["AKS-KedaPreview"]}`,
+			expectError: true,
+		},
+		{
+			name:        "preview feature with empty key",
+			content:     `{"previewFeatures": ["Microsoft.ContainerService/"]}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid JSON",
+			content:     `{"resourceProviders": [}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "baseline.jsonc")
+			writeFile(t, path, tt.content)
+
+			baseline, err := LoadBaseline(path)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("LoadBaseline() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && !reflect.DeepEqual(baseline, tt.expected) {
+				t.Errorf("LoadBaseline() = %+v, expected %+v", baseline, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBaselinePlanReasons(t *testing.T) {
+	baseline := &Baseline{
+		ResourceProviders: []string{"Microsoft.Compute", "Microsoft.KeyVault", "Microsoft.Quantum", "Microsoft.Storage"},
+		PreviewFeatures:   []string{"Microsoft.Compute/EncryptionAtHost", "Microsoft.Compute/Missing"},
+	}
+	targetState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Compute", "Registered"),
+			provider("Microsoft.KeyVault", "NotRegistered"),
+			provider("Microsoft.Storage", "Unregistering"),
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/EncryptionAtHost", "NotRegistered"),
+		},
+	}
+
+	plan := buildPlan(baseline.toState("baseline test", ReasonRequiredByBaseline), targetState, &planOptions{mode: "additive"})
+
+	tests := []struct {
+		name     string
+		actual   map[string]string
+		expected map[string]string
+	}{
+		{
+			name:   "RPs",
+			actual: rpReasons(plan.RpRegistrations),
+			expected: map[string]string{
+				"Microsoft.KeyVault": ReasonRequiredByBaseline,
+				"Microsoft.Quantum":  ReasonRequiredByBaseline,
+				"Microsoft.Storage":  ReasonUnregisteringInTarget,
+			},
+		},
+		{
+			name:   "preview features",
+			actual: featureReasons(plan.PreviewFeatures),
+			expected: map[string]string{
+				"Microsoft.Compute/EncryptionAtHost": ReasonRequiredByBaseline,
+				"Microsoft.Compute/Missing":          ReasonRequiredByBaseline,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.actual, tt.expected) {
+				t.Errorf("reasons = %v, expected %v", tt.actual, tt.expected)
+			}
+		})
+	}
+}
+
+func rpReasons(rpRegs []RpRegistration) map[string]string {
+	reasons := make(map[string]string)
+	for _, rp := range rpRegs {
+		reasons[rp.Namespace] = rp.Reason
+	}
+	return reasons
+}
+
+func featureReasons(prFeats []PreviewFeature) map[string]string {
+	reasons := make(map[string]string)
+	for _, feat := range prFeats {
+		reasons[feat.Namespace+"/"+feat.Key] = feat.Reason
+	}
+	return reasons
+}
@@ -0,0 +1,213 @@
+package plan
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+//go:embed cloud_mapping.json
+var builtinCloudMapping []byte
+
+// cloudMappings holds the mapping of each "<source cloud>-><target cloud>" pair, eg: "public->usgovernment".
+type cloudMappings map[string]*cloudPairMapping
+
+// cloudPairMapping maps lower-cased source RP namespaces and "Namespace/Key" preview features to their name in the
+// target cloud, an empty name marks an entry that never exists in the target cloud.
+type cloudPairMapping struct {
+	ResourceProviders map[string]string `json:"resourceProviders"`
+	PreviewFeatures   map[string]string `json:"previewFeatures"`
+}
+
+// loadCloudMappings returns the built-in mappings with the entries of the override files applied on top, source names
+// are lower-cased once here so lookups are a map access.
+func loadCloudMappings(overridePaths []string) (cloudMappings, error) {
+	var builtin cloudMappings
+	if err := json.Unmarshal(jsonutil.StripJSONComments(builtinCloudMapping), &builtin); err != nil {
+		return nil, fmt.Errorf("failed to deserialize built-in cloud mapping: %w", err)
+	}
+	mappings := cloudMappings{}
+	mappings.apply(builtin)
+
+	for _, path := range overridePaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cloud mapping file %s: %w", path, err)
+		}
+
+		var overrides cloudMappings
+		if err := json.Unmarshal(jsonutil.StripJSONComments(data), &overrides); err != nil {
+			return nil, fmt.Errorf("failed to deserialize cloud mapping from %s: %w", path, err)
+		}
+
+		for pair := range overrides {
+			if _, _, valid := strings.Cut(pair, "->"); !valid {
+				return nil, fmt.Errorf("bad cloud pair %q in %s, expected <source>-><target>, eg: public->usgovernment", pair, path)
+			}
+		}
+		mappings.apply(overrides)
+	}
+
+	return mappings, nil
+}
+
+// apply sets the entries of the overrides on top of the mappings, keyed by lower-cased source name.
+func (m cloudMappings) apply(overrides cloudMappings) {
+	for pair, override := range overrides {
+		if override == nil {
+			continue
+		}
+		mapping := m.pair(pair)
+		for name, targetName := range override.ResourceProviders {
+			mapping.ResourceProviders[strings.ToLower(name)] = targetName
+		}
+		for name, targetName := range override.PreviewFeatures {
+			mapping.PreviewFeatures[strings.ToLower(name)] = targetName
+		}
+	}
+}
+
+// get returns the mapping of a cloud pair without modifying the mappings, so plans can be built concurrently.
+func (m cloudMappings) get(pair string) *cloudPairMapping {
+	if mapping := m[pair]; mapping != nil {
+		return mapping
+	}
+	return &cloudPairMapping{}
+}
+
+// pair returns the mapping of a cloud pair, adding it when missing.
+func (m cloudMappings) pair(pair string) *cloudPairMapping {
+	mapping, exists := m[pair]
+	if !exists || mapping == nil {
+		mapping = &cloudPairMapping{}
+		m[pair] = mapping
+	}
+	if mapping.ResourceProviders == nil {
+		mapping.ResourceProviders = make(map[string]string)
+	}
+	if mapping.PreviewFeatures == nil {
+		mapping.PreviewFeatures = make(map[string]string)
+	}
+	return mapping
+}
+
+// crossCloudPair returns the "<source>-><target>" pair when the states are in different clouds.
+func crossCloudPair(srcState *SubscriptionState, targetState *SubscriptionState) (pair string, crossCloud bool) {
+	srcCloud, _ := config.NormalizeCloud(srcState.Cloud)
+	targetCloud, _ := config.NormalizeCloud(targetState.Cloud)
+	return srcCloud + "->" + targetCloud, srcCloud != targetCloud
+}
+
+// lookup returns the target cloud name of an entry, names are matched case-insensitively against the lower-cased keys
+// built by loadCloudMappings.
+func lookup(names map[string]string, name string) (targetName string, mapped bool) {
+	if targetName, mapped = names[strings.ToLower(name)]; mapped {
+		return targetName, true
+	}
+	return name, false
+}
+
+// mapToCloud returns a copy of the source state with its entries renamed for the target cloud, and the registered or
+// pending entries that never exist there.
+func (m *cloudPairMapping) mapToCloud(state *SubscriptionState) (mapped *SubscriptionState, unavailableRPs []string, unavailableFeatures []string) {
+	copied := *state
+	mapped = &copied
+	mapped.ResourceProviders = []*armresources.Provider{}
+	mapped.PreviewFeatures = []*armfeatures.FeatureResult{}
+	mapped.RpSources = renameKeys(state.RpSources, m.ResourceProviders)
+	mapped.FeatureSources = renameKeys(state.FeatureSources, m.PreviewFeatures)
+	mapped.ResourceCounts = renameKeys(state.ResourceCounts, m.ResourceProviders)
+	mapped.FeatureMetadata = renameKeys(state.FeatureMetadata, m.PreviewFeatures)
+
+	for _, rp := range state.ResourceProviders {
+		namespace := pointer.From(rp.Namespace)
+		targetNamespace, _ := lookup(m.ResourceProviders, namespace)
+		if targetNamespace == "" {
+			if ParseRegistrationState(pointer.From(rp.RegistrationState)).IsRegisteredOrPending() {
+				unavailableRPs = append(unavailableRPs, namespace)
+			}
+			continue
+		}
+		if targetNamespace != namespace {
+			renamed := *rp
+			renamed.Namespace = pointer.To(targetNamespace)
+			rp = &renamed
+		}
+		mapped.ResourceProviders = append(mapped.ResourceProviders, rp)
+	}
+
+	for _, feat := range state.PreviewFeatures {
+		name := pointer.From(feat.Name)
+		targetName, mappedFeature := lookup(m.PreviewFeatures, name)
+		if !mappedFeature {
+			// features follow their namespace unless mapped on their own
+			namespace, key, _ := strings.Cut(name, "/")
+			if targetNamespace, mappedNamespace := lookup(m.ResourceProviders, namespace); mappedNamespace {
+				targetName = ""
+				if targetNamespace != "" {
+					targetName = targetNamespace + "/" + key
+				}
+			}
+		}
+		if targetName == "" {
+			if ParseRegistrationState(getState(feat)).IsRegisteredOrPending() {
+				unavailableFeatures = append(unavailableFeatures, name)
+			}
+			continue
+		}
+		if targetName != name {
+			renamed := *feat
+			renamed.Name = pointer.To(targetName)
+			feat = &renamed
+		}
+		mapped.PreviewFeatures = append(mapped.PreviewFeatures, feat)
+	}
+
+	return
+}
+
+// renameKeys returns a copy of a map keyed by lower-cased names with the keys renamed for the target cloud.
+func renameKeys[V any](values map[string]V, names map[string]string) map[string]V {
+	if values == nil {
+		return nil
+	}
+	renamed := make(map[string]V, len(values))
+	for key, value := range values {
+		if targetName, mapped := lookup(names, key); mapped && targetName != "" {
+			key = strings.ToLower(targetName)
+		}
+		renamed[key] = value
+	}
+	return renamed
+}
+
+// markUnavailableInTargetCloud reports the entries the target cloud doesn't have at all as UnavailableInTargetCloud,
+// the target subscription lists every RP and preview feature of its cloud whether registered or not.
+func markUnavailableInTargetCloud(plan *Plan, unavailableRPs []string, unavailableFeatures []string) {
+	for i := range plan.RpRegistrations {
+		if plan.RpRegistrations[i].Reason == ReasonNotFoundInTarget {
+			plan.RpRegistrations[i].Reason = ReasonUnavailableInTargetCloud
+		}
+	}
+	for _, namespace := range unavailableRPs {
+		plan.RpRegistrations = append(plan.RpRegistrations, RpRegistration{Namespace: namespace, Reason: ReasonUnavailableInTargetCloud})
+	}
+
+	for i := range plan.PreviewFeatures {
+		if plan.PreviewFeatures[i].Reason == ReasonNotFoundInTarget {
+			plan.PreviewFeatures[i].Reason = ReasonUnavailableInTargetCloud
+		}
+	}
+	for _, name := range unavailableFeatures {
+		namespace, key, _ := strings.Cut(name, "/")
+		plan.PreviewFeatures = append(plan.PreviewFeatures, PreviewFeature{Key: key, Namespace: namespace, Reason: ReasonUnavailableInTargetCloud})
+	}
+}
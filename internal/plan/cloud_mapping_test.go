@@ -0,0 +1,123 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestCrossCloudPlan(t *testing.T) {
+	mappingFile := filepath.Join(t.TempDir(), "mapping.jsonc")
+	mapping := `{
+  "public->usgovernment": {
+    "resourceProviders": { "Microsoft.OldName": "Microsoft.NewName" },
+    "previewFeatures": { "Microsoft.Compute/PublicOnly": "" }
+  }
+}`
+	if err := os.WriteFile(mappingFile, []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := loadCloudMappings([]string{mappingFile})
+	if err != nil {
+		t.Fatalf("loadCloudMappings() error = %v", err)
+	}
+
+	srcState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Compute", "Registered"),
+			provider("Microsoft.OldName", "Registered"),
+			provider("Microsoft.Quantum", "Registered"),
+			provider("Microsoft.VideoIndexer", "Registered"),
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/PublicOnly", "Registered"),
+			feature("Microsoft.Compute/EncryptionAtHost", "Registered"),
+		},
+	}
+	targetState := &SubscriptionState{
+		Cloud: "usgovernment",
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Compute", "Registered"),
+			provider("Microsoft.NewName", "NotRegistered"),
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/EncryptionAtHost", "NotRegistered"),
+		},
+	}
+
+	plan := buildPlan(srcState, targetState, &planOptions{mode: "additive", cloudMappings: mappings})
+
+	expectedRPs := []RpRegistration{
+		{Namespace: "Microsoft.NewName", Reason: "NotRegisteredInTarget"},
+		{Namespace: "Microsoft.VideoIndexer", Reason: ReasonUnavailableInTargetCloud},
+		{Namespace: "Microsoft.Quantum", Reason: ReasonUnavailableInTargetCloud},
+	}
+	if !reflect.DeepEqual(plan.RpRegistrations, expectedRPs) {
+		t.Errorf("RpRegistrations = %+v, expected %+v", plan.RpRegistrations, expectedRPs)
+	}
+
+	expectedFeatures := []PreviewFeature{
+		{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", Reason: "NotRegisteredInTarget", Approval: ApprovalAuto},
+		{Key: "PublicOnly", Namespace: "Microsoft.Compute", Reason: ReasonUnavailableInTargetCloud},
+	}
+	if !reflect.DeepEqual(plan.PreviewFeatures, expectedFeatures) {
+		t.Errorf("PreviewFeatures = %+v, expected %+v", plan.PreviewFeatures, expectedFeatures)
+	}
+}
+
+func TestSameCloudPlanKeepsNotFoundInTarget(t *testing.T) {
+	srcState := &SubscriptionState{
+		Cloud:             "public",
+		ResourceProviders: []*armresources.Provider{provider("Microsoft.Quantum", "Registered")},
+	}
+	targetState := &SubscriptionState{}
+
+	plan := buildPlan(srcState, targetState, &planOptions{mode: "additive"})
+
+	expected := []RpRegistration{{Namespace: "Microsoft.Quantum", Reason: "NotFoundInTarget"}}
+	if !reflect.DeepEqual(plan.RpRegistrations, expected) {
+		t.Errorf("RpRegistrations = %+v, expected %+v", plan.RpRegistrations, expected)
+	}
+}
+
+func TestLoadCloudMappingsOverridesCaseInsensitively(t *testing.T) {
+	mappingFile := filepath.Join(t.TempDir(), "mapping.jsonc")
+	mapping := `{
+  // the built-in mapping has Microsoft.Quantum unavailable in usgovernment
+  "public->usgovernment": { "resourceProviders": { "microsoft.quantum": "Microsoft.Quantum" } }
+}`
+	if err := os.WriteFile(mappingFile, []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := loadCloudMappings([]string{mappingFile})
+	if err != nil {
+		t.Fatalf("loadCloudMappings() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		expectedName   string
+		expectedMapped bool
+	}{
+		{name: "Microsoft.Quantum", expectedName: "Microsoft.Quantum", expectedMapped: true},
+		{name: "MICROSOFT.DATADOG", expectedName: "", expectedMapped: true},
+		{name: "Microsoft.Compute", expectedName: "Microsoft.Compute", expectedMapped: false},
+	}
+
+	names := mappings.get("public->usgovernment").ResourceProviders
+	if _, duplicated := names["Microsoft.Quantum"]; duplicated {
+		t.Fatalf("override added a second Microsoft.Quantum entry instead of replacing it: %v", names)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targetName, mapped := lookup(names, tt.name)
+			if targetName != tt.expectedName || mapped != tt.expectedMapped {
+				t.Errorf("lookup(%q) = %q, %v, expected %q, %v", tt.name, targetName, mapped, tt.expectedName, tt.expectedMapped)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/credential"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// FeatureMetadata describes a preview feature as returned by the subscription feature registrations API, so the plan
+// reviewer can tell what a feature does without looking it up.
+type FeatureMetadata struct {
+	Description       string     `json:"description,omitempty"`
+	DocumentationLink string     `json:"documentationLink,omitempty"`
+	ApprovalType      string     `json:"approvalType,omitempty"`     // ApprovalRequired | AutoApproval | NotSpecified
+	RegistrationDate  *time.Time `json:"registrationDate,omitempty"` // when the feature was registered in the subscription it was read from
+	ReleaseDate       *time.Time `json:"releaseDate,omitempty"`
+}
+
+// getFeatureMetadata returns the metadata of the preview features registered in the subscription, keyed by lower-cased
+// "Namespace/Key". The API only lists features with a registration, whatever its state.
+func getFeatureMetadata(ctx context.Context, config *config.Config) (map[string]*FeatureMetadata, error) {
+	cred, err := credential.BuildCredential(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	client, err := armfeatures.NewSubscriptionFeatureRegistrationsClient(config.SubscriptionID, cred, credential.ClientOptions(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription feature registrations client: %w", err)
+	}
+
+	metadata := make(map[string]*FeatureMetadata)
+	pager := client.NewListAllBySubscriptionPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get subscription feature registrations page: %w", err)
+		}
+
+		for _, registration := range page.Value {
+			if registration.Properties == nil {
+				continue
+			}
+			props := registration.Properties
+			name := pointer.From(props.ProviderNamespace) + "/" + pointer.From(props.FeatureName)
+			metadata[strings.ToLower(name)] = &FeatureMetadata{
+				Description:       pointer.From(props.Description),
+				DocumentationLink: pointer.From(props.DocumentationLink),
+				ApprovalType:      string(pointer.From(props.ApprovalType)),
+				RegistrationDate:  props.RegistrationDate,
+				ReleaseDate:       props.ReleaseDate,
+			}
+		}
+	}
+
+	return metadata, nil
+}
+
+// attachFeatureMetadata sets the metadata of every preview feature entry from the first map knowing it, callers pass
+// the source metadata first since the target usually has no registration for features missing there.
+func attachFeatureMetadata(prFeats []PreviewFeature, metadata ...map[string]*FeatureMetadata) {
+	for i := range prFeats {
+		key := strings.ToLower(prFeats[i].Namespace + "/" + prFeats[i].Key)
+		for _, m := range metadata {
+			if found, exists := m[key]; exists {
+				prFeats[i].FeatureMetadata = found
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package plan
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+)
+
+func TestAttachFeatureMetadata(t *testing.T) {
+	srcState := &SubscriptionState{
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "Registered"),
+			feature("Microsoft.Compute/EncryptionAtHost", "Registered"),
+			feature("Microsoft.Cache/Foo", "Registered"),
+		},
+		FeatureMetadata: map[string]*FeatureMetadata{
+			"microsoft.network/allowmultiplepeeringlinksbetweenvnets": {
+				Description:  "Allow multiple peering links between the same virtual networks",
+				ApprovalType: "AutoApproval",
+			},
+		},
+	}
+	targetState := &SubscriptionState{
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/EncryptionAtHost", "Unregistered"),
+		},
+		FeatureMetadata: map[string]*FeatureMetadata{
+			"microsoft.compute/encryptionathost": {ApprovalType: "AutoApproval"},
+		},
+	}
+
+	plan := buildPlan(srcState, targetState, &planOptions{mode: "additive"})
+
+	expected := []PreviewFeature{
+		{
+			Key:       "AllowMultiplePeeringLinksBetweenVnets",
+			Namespace: "Microsoft.Network",
+			Reason:    "NotFoundInTarget",
+			Approval:  ApprovalAuto,
+			FeatureMetadata: &FeatureMetadata{
+				Description:  "Allow multiple peering links between the same virtual networks",
+				ApprovalType: "AutoApproval",
+			},
+		},
+		{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", Reason: "NotRegisteredInTarget", Approval: ApprovalAuto, FeatureMetadata: &FeatureMetadata{ApprovalType: "AutoApproval"}},
+		{Key: "Foo", Namespace: "Microsoft.Cache", Reason: "NotFoundInTarget", Approval: ApprovalAuto},
+	}
+	if !reflect.DeepEqual(plan.PreviewFeatures, expected) {
+		t.Errorf("PreviewFeatures = %+v, expected %+v", plan.PreviewFeatures, expected)
+	}
+
+	// the metadata is inlined next to the other fields of the entry
+	data, err := json.Marshal(plan.PreviewFeatures[1])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"approval":"Auto","approvalType":"AutoApproval"`) {
+		t.Errorf("json.Marshal() = %s, expected inlined approvalType", data)
+	}
+}
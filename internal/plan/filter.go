@@ -0,0 +1,133 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+)
+
+// Filter narrows the plan entries down with glob patterns. A pattern without a slash is matched against the namespace
+// of both RPs and preview features, eg: "Microsoft.Classic*". A pattern with a slash is matched against the
+// "Namespace/Key" of preview features, eg: "Microsoft.ContainerService/*"; as an include it also keeps the RP of the
+// matching namespace since its features can't be registered without it.
+type Filter struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+func LoadFilter(path string) (*Filter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filter file %s: %w", path, err)
+	}
+
+	var filter Filter
+	if err := json.Unmarshal(jsonutil.StripJSONComments(data), &filter); err != nil {
+		return nil, fmt.Errorf("failed to deserialize filter from %s: %w", path, err)
+	}
+
+	return &filter, nil
+}
+
+func (f *Filter) validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return fmt.Errorf("bad filter pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (f *Filter) isSet() bool {
+	return len(f.Include) > 0 || len(f.Exclude) > 0
+}
+
+func (f *Filter) includesRP(namespace string) bool {
+	namespace = strings.ToLower(namespace)
+
+	for _, pattern := range f.Exclude {
+		if !strings.Contains(pattern, "/") && GlobMatch(pattern, namespace) {
+			return false
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		namespacePattern, _, _ := strings.Cut(pattern, "/")
+		if GlobMatch(namespacePattern, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) includesFeature(namespace string, key string) bool {
+	name := strings.ToLower(namespace + "/" + key)
+	namespace = strings.ToLower(namespace)
+
+	for _, pattern := range f.Exclude {
+		if featurePatternMatch(pattern, namespace, name) {
+			return false
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		if featurePatternMatch(pattern, namespace, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func featurePatternMatch(pattern string, namespace string, name string) bool {
+	if strings.Contains(pattern, "/") {
+		return GlobMatch(pattern, name)
+	}
+	return GlobMatch(pattern, namespace)
+}
+
+// GlobMatch matches a value against a glob pattern case-insensitively, eg: "Microsoft.Classic*" matches
+// "microsoft.classiccompute". A malformed pattern never matches.
+func GlobMatch(pattern string, value string) bool {
+	matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return matched
+}
+
+// filterPlan moves the plan entries rejected by the filter to the excluded section of the plan.
+func filterPlan(plan *Plan, filter *Filter) {
+	excluded := &Excluded{
+		RpRegistrations: []RpRegistration{},
+		PreviewFeatures: []PreviewFeature{},
+	}
+
+	var rpRegs []RpRegistration
+	for _, rpReg := range plan.RpRegistrations {
+		if filter.includesRP(rpReg.Namespace) {
+			rpRegs = append(rpRegs, rpReg)
+		} else {
+			excluded.RpRegistrations = append(excluded.RpRegistrations, rpReg)
+		}
+	}
+
+	var prFeats []PreviewFeature
+	for _, feature := range plan.PreviewFeatures {
+		if filter.includesFeature(feature.Namespace, feature.Key) {
+			prFeats = append(prFeats, feature)
+		} else {
+			excluded.PreviewFeatures = append(excluded.PreviewFeatures, feature)
+		}
+	}
+
+	plan.RpRegistrations = rpRegs
+	plan.PreviewFeatures = prFeats
+	plan.Excluded = excluded
+}
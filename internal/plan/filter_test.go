@@ -0,0 +1,53 @@
+package plan
+
+import "testing"
+
+func TestFilterIncludesRP(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    Filter
+		namespace string
+		expected  bool
+	}{
+		{name: "No patterns", namespace: "Microsoft.Cache", expected: true},
+		{name: "Excluded by namespace glob", filter: Filter{Exclude: []string{"Microsoft.Classic*"}}, namespace: "Microsoft.ClassicCompute", expected: false},
+		{name: "Exclude ignores case", filter: Filter{Exclude: []string{"microsoft.classic*"}}, namespace: "Microsoft.ClassicNetwork", expected: false},
+		{name: "Feature exclude doesn't exclude the RP", filter: Filter{Exclude: []string{"Microsoft.ContainerService/*"}}, namespace: "Microsoft.ContainerService", expected: true},
+		{name: "Feature include keeps the RP", filter: Filter{Include: []string{"Microsoft.ContainerService/*"}}, namespace: "Microsoft.ContainerService", expected: true},
+		{name: "Not included", filter: Filter{Include: []string{"Microsoft.ContainerService/*"}}, namespace: "Microsoft.Cache", expected: false},
+		{name: "Exclude wins over include", filter: Filter{Include: []string{"Microsoft.*"}, Exclude: []string{"Microsoft.Cache"}}, namespace: "Microsoft.Cache", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.filter.includesRP(tt.namespace); actual != tt.expected {
+				t.Errorf("includesRP(%q) = %v, expected %v", tt.namespace, actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterIncludesFeature(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    Filter
+		namespace string
+		key       string
+		expected  bool
+	}{
+		{name: "No patterns", namespace: "Microsoft.Network", key: "AllowX", expected: true},
+		{name: "Excluded by namespace glob", filter: Filter{Exclude: []string{"Microsoft.Classic*"}}, namespace: "Microsoft.ClassicCompute", key: "Foo", expected: false},
+		{name: "Excluded by feature glob", filter: Filter{Exclude: []string{"Microsoft.ContainerService/AKS-*"}}, namespace: "Microsoft.ContainerService", key: "AKS-KedaPreview", expected: false},
+		{name: "Included by feature glob", filter: Filter{Include: []string{"Microsoft.ContainerService/*"}}, namespace: "Microsoft.ContainerService", key: "AKS-KedaPreview", expected: true},
+		{name: "Not included", filter: Filter{Include: []string{"Microsoft.ContainerService/*"}}, namespace: "Microsoft.Network", key: "AllowX", expected: false},
+		{name: "Included by namespace", filter: Filter{Include: []string{"Microsoft.Network"}}, namespace: "Microsoft.Network", key: "AllowX", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.filter.includesFeature(tt.namespace, tt.key); actual != tt.expected {
+				t.Errorf("includesFeature(%q, %q) = %v, expected %v", tt.namespace, tt.key, actual, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package plan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// mergeStrategy decides how many sources must have an RP / preview feature registered for it to be planned.
+type mergeStrategy struct {
+	name   string // union | intersection | quorum=N
+	quorum int
+}
+
+func parseMergeStrategy(value string) (*mergeStrategy, error) {
+	switch {
+	case value == "union":
+		return &mergeStrategy{name: value, quorum: 1}, nil
+	case value == "intersection":
+		return &mergeStrategy{name: value}, nil
+	case strings.HasPrefix(value, "quorum="):
+		quorum, err := strconv.Atoi(strings.TrimPrefix(value, "quorum="))
+		if err != nil || quorum < 1 {
+			return nil, fmt.Errorf("bad merge strategy %q, expected quorum=N where N is a positive number", value)
+		}
+		return &mergeStrategy{name: value, quorum: quorum}, nil
+	default:
+		return nil, fmt.Errorf("unknown merge strategy %q, expected union | intersection | quorum=N", value)
+	}
+}
+
+func (m *mergeStrategy) required(sourceCount int) int {
+	if m.name == "intersection" {
+		return sourceCount
+	}
+	return m.quorum
+}
+
+// mergeStates combines multiple source states into one holding the RPs and preview features registered in enough
+// sources according to the strategy, along with the sources that contributed each of them. The sources must all be in
+// the same cloud since RP and preview feature names differ between clouds.
+func mergeStates(states []*SubscriptionState, strategy *mergeStrategy) (*SubscriptionState, error) {
+	required := strategy.required(len(states))
+	if required > len(states) {
+		return nil, fmt.Errorf("merge strategy %s requires more than the %d sources given", strategy.name, len(states))
+	}
+
+	cloud, _ := config.NormalizeCloud(states[0].Cloud)
+	for _, state := range states[1:] {
+		if stateCloud, _ := config.NormalizeCloud(state.Cloud); stateCloud != cloud {
+			return nil, fmt.Errorf("source %s is in cloud %s while %s is in cloud %s, only sources of the same cloud can be merged",
+				state.label(), stateCloud, states[0].label(), cloud)
+		}
+	}
+
+	merged := &SubscriptionState{
+		Origin:         fmt.Sprintf("%s of %d sources", strategy.name, len(states)),
+		Cloud:          states[0].Cloud,
+		RpSources:      make(map[string][]string),
+		FeatureSources: make(map[string][]string),
+	}
+
+	rpsByNamespace := make(map[string]*armresources.Provider)
+	rpOrder := []string{}
+	featuresByName := make(map[string]*armfeatures.FeatureResult)
+	featureOrder := []string{}
+
+	for _, state := range states {
+		for _, rp := range state.ResourceProviders {
+			if rp == nil || !ParseRegistrationState(pointer.From(rp.RegistrationState)).IsRegisteredOrPending() {
+				continue
+			}
+			key := strings.ToLower(pointer.From(rp.Namespace))
+			if _, exists := rpsByNamespace[key]; !exists {
+				rpsByNamespace[key] = rp
+				rpOrder = append(rpOrder, key)
+			}
+			merged.RpSources[key] = appendSource(merged.RpSources[key], state.label())
+		}
+
+		for _, feat := range state.PreviewFeatures {
+			if feat == nil || !ParseRegistrationState(getState(feat)).IsRegisteredOrPending() {
+				continue
+			}
+			key := strings.ToLower(pointer.From(feat.Name))
+			if _, exists := featuresByName[key]; !exists {
+				featuresByName[key] = feat
+				featureOrder = append(featureOrder, key)
+			}
+			merged.FeatureSources[key] = appendSource(merged.FeatureSources[key], state.label())
+		}
+
+		for key, metadata := range state.FeatureMetadata {
+			if merged.FeatureMetadata == nil {
+				merged.FeatureMetadata = make(map[string]*FeatureMetadata)
+			}
+			if _, exists := merged.FeatureMetadata[key]; !exists {
+				merged.FeatureMetadata[key] = metadata
+			}
+		}
+	}
+
+	for _, key := range rpOrder {
+		if len(merged.RpSources[key]) >= required {
+			merged.ResourceProviders = append(merged.ResourceProviders, rpsByNamespace[key])
+		} else {
+			delete(merged.RpSources, key)
+		}
+	}
+
+	for _, key := range featureOrder {
+		if len(merged.FeatureSources[key]) >= required {
+			merged.PreviewFeatures = append(merged.PreviewFeatures, featuresByName[key])
+		} else {
+			delete(merged.FeatureSources, key)
+		}
+	}
+
+	return merged, nil
+}
+
+// appendSource adds the source label once, a source listing an entry under case-variant names still counts once
+// towards the quorum. Sources are merged one after the other so only the last label can be the same.
+func appendSource(sources []string, label string) []string {
+	if len(sources) > 0 && sources[len(sources)-1] == label {
+		return sources
+	}
+	return append(sources, label)
+}
+
+func attachRpSources(rpRegs []RpRegistration, rpSources map[string][]string) {
+	for i := range rpRegs {
+		rpRegs[i].Sources = rpSources[strings.ToLower(rpRegs[i].Namespace)]
+	}
+}
+
+func attachFeatureSources(prFeats []PreviewFeature, featureSources map[string][]string) {
+	for i := range prFeats {
+		prFeats[i].Sources = featureSources[strings.ToLower(prFeats[i].Namespace+"/"+prFeats[i].Key)]
+	}
+}
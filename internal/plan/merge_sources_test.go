@@ -0,0 +1,158 @@
+package plan
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+func TestMergeStates(t *testing.T) {
+	states := []*SubscriptionState{
+		{
+			SubscriptionID: "sub-a",
+			ResourceProviders: []*armresources.Provider{
+				provider("Microsoft.Cache", "Registered"),
+				provider("Microsoft.Compute", "Registered"),
+			},
+			PreviewFeatures: []*armfeatures.FeatureResult{
+				feature("Microsoft.Network/Foo", "Registered"),
+			},
+		},
+		{
+			SubscriptionID: "sub-b",
+			ResourceProviders: []*armresources.Provider{
+				provider("microsoft.cache", "Pending"),
+				provider("Microsoft.Compute", "NotRegistered"),
+			},
+			PreviewFeatures: []*armfeatures.FeatureResult{
+				feature("Microsoft.Network/Foo", "Registered"),
+				feature("Microsoft.Network/Bar", "Registered"),
+			},
+		},
+		{
+			SubscriptionID: "sub-c",
+			ResourceProviders: []*armresources.Provider{
+				provider("Microsoft.Cache", "Registered"),
+				provider("Microsoft.Compute", "Registered"),
+			},
+		},
+	}
+
+	tests := []struct {
+		strategy         string
+		expectedRPs      []string
+		expectedFeatures []string
+	}{
+		{strategy: "union", expectedRPs: []string{"Microsoft.Cache", "Microsoft.Compute"}, expectedFeatures: []string{"Microsoft.Network/Foo", "Microsoft.Network/Bar"}},
+		{strategy: "intersection", expectedRPs: []string{"Microsoft.Cache"}, expectedFeatures: nil},
+		{strategy: "quorum=2", expectedRPs: []string{"Microsoft.Cache", "Microsoft.Compute"}, expectedFeatures: []string{"Microsoft.Network/Foo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			strategy, err := parseMergeStrategy(tt.strategy)
+			if err != nil {
+				t.Fatalf("parseMergeStrategy() error = %v", err)
+			}
+
+			merged, err := mergeStates(states, strategy)
+			if err != nil {
+				t.Fatalf("mergeStates() error = %v", err)
+			}
+
+			var rps, features []string
+			for _, rp := range merged.ResourceProviders {
+				rps = append(rps, pointer.From(rp.Namespace))
+			}
+			for _, feat := range merged.PreviewFeatures {
+				features = append(features, pointer.From(feat.Name))
+			}
+
+			if !reflect.DeepEqual(rps, tt.expectedRPs) {
+				t.Errorf("merged RPs = %v, expected %v", rps, tt.expectedRPs)
+			}
+			if !reflect.DeepEqual(features, tt.expectedFeatures) {
+				t.Errorf("merged features = %v, expected %v", features, tt.expectedFeatures)
+			}
+		})
+	}
+
+	strategy, _ := parseMergeStrategy("union")
+	merged, _ := mergeStates(states, strategy)
+	if sources := merged.RpSources["microsoft.cache"]; !reflect.DeepEqual(sources, []string{"sub-a", "sub-b", "sub-c"}) {
+		t.Errorf("Microsoft.Cache sources = %v", sources)
+	}
+}
+
+func TestMergeStatesQuorumCountsEachSourceOnce(t *testing.T) {
+	states := []*SubscriptionState{
+		{
+			SubscriptionID: "sub-a",
+			ResourceProviders: []*armresources.Provider{
+				provider("Microsoft.Foo", "Registered"),
+				provider("microsoft.foo", "Registered"),
+			},
+			PreviewFeatures: []*armfeatures.FeatureResult{
+				feature("Microsoft.Foo/Bar", "Registered"),
+				feature("MICROSOFT.FOO/BAR", "Registered"),
+			},
+		},
+		{SubscriptionID: "sub-b"},
+	}
+
+	strategy, _ := parseMergeStrategy("quorum=2")
+	merged, err := mergeStates(states, strategy)
+	if err != nil {
+		t.Fatalf("mergeStates() error = %v", err)
+	}
+
+	if len(merged.ResourceProviders) != 0 || len(merged.PreviewFeatures) != 0 {
+		t.Errorf("merged = %d RPs, %d preview features, expected none since only sub-a has them",
+			len(merged.ResourceProviders), len(merged.PreviewFeatures))
+	}
+
+	strategy, _ = parseMergeStrategy("union")
+	merged, _ = mergeStates(states, strategy)
+	if sources := merged.RpSources["microsoft.foo"]; !reflect.DeepEqual(sources, []string{"sub-a"}) {
+		t.Errorf("Microsoft.Foo sources = %v, expected [sub-a]", sources)
+	}
+}
+
+func TestMergeStatesRejectsMixedClouds(t *testing.T) {
+	tests := []struct {
+		name        string
+		clouds      []string
+		expectError bool
+	}{
+		{name: "same cloud", clouds: []string{"usgovernment", "AzureUSGovernment"}},
+		{name: "default cloud is public", clouds: []string{"", "public"}},
+		{name: "mixed clouds", clouds: []string{"public", "usgovernment"}, expectError: true},
+	}
+
+	strategy, _ := parseMergeStrategy("union")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var states []*SubscriptionState
+			for i, cloud := range tt.clouds {
+				states = append(states, &SubscriptionState{SubscriptionID: "sub-" + strconv.Itoa(i), Cloud: cloud})
+			}
+
+			_, err := mergeStates(states, strategy)
+			if (err != nil) != tt.expectError {
+				t.Errorf("mergeStates() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestParseMergeStrategyInvalid(t *testing.T) {
+	for _, value := range []string{"", "all", "quorum=", "quorum=0", "quorum=x"} {
+		if _, err := parseMergeStrategy(value); err == nil {
+			t.Errorf("parseMergeStrategy(%q) expected error", value)
+		}
+	}
+}
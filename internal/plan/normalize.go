@@ -0,0 +1,63 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/featurename"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// SkippedRecord is an RP or preview feature record of a side that couldn't be planned, eg: a feature name without a
+// namespace in a hand-edited `az feature list` output.
+type SkippedRecord struct {
+	Side    string `json:"side"`    // source | target
+	Kind    string `json:"kind"`    // resourceProvider | previewFeature
+	Name    string `json:"name"`    // as found in the record, may be empty
+	Problem string `json:"problem"` // eg: "expected Namespace/Key"
+}
+
+// sanitizeState returns a copy of the state without the malformed RP and preview feature records, along with the
+// skipped records, so the planner can rely on every name being well-formed.
+func sanitizeState(state *SubscriptionState, side string) (sanitized *SubscriptionState, skipped []SkippedRecord) {
+	copied := *state
+	sanitized = &copied
+	sanitized.ResourceProviders = []*armresources.Provider{}
+	sanitized.PreviewFeatures = []*armfeatures.FeatureResult{}
+
+	for _, rp := range state.ResourceProviders {
+		if rp == nil || strings.TrimSpace(pointer.From(rp.Namespace)) == "" {
+			skipped = append(skipped, SkippedRecord{Side: side, Kind: "resourceProvider", Problem: "missing namespace"})
+			continue
+		}
+		sanitized.ResourceProviders = append(sanitized.ResourceProviders, rp)
+	}
+
+	for _, feat := range state.PreviewFeatures {
+		var name string
+		if feat != nil {
+			name = pointer.From(feat.Name)
+		}
+		if _, _, err := featurename.Split(name); err != nil {
+			skipped = append(skipped, SkippedRecord{Side: side, Kind: "previewFeature", Name: name, Problem: err.Error()})
+			continue
+		}
+		sanitized.PreviewFeatures = append(sanitized.PreviewFeatures, feat)
+	}
+
+	return
+}
+
+func printSkippedSummary(w io.Writer, skipped []SkippedRecord) {
+	if len(skipped) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "⚠️  Skipped %d malformed records, see \"skippedRecords\" in the plan\n", len(skipped))
+	for _, record := range skipped {
+		fmt.Fprintf(w, "  - %s %s %q: %s\n", record.Side, record.Kind, record.Name, record.Problem)
+	}
+}
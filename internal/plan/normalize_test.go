@@ -0,0 +1,61 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestBuildPlanWithMalformedAndCaseVariantNames(t *testing.T) {
+	srcState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("microsoft.cache", "Registered"),
+			provider("MICROSOFT.CACHE", "Registered"),
+			provider("Microsoft.Web", "Registered"),
+			provider("", "Registered"),
+			nil,
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("microsoft.compute/encryptionathost", "Registered"),
+			feature("EncryptionAtHost", "Registered"),
+			feature("Microsoft.Network/", "Registered"),
+		},
+	}
+	targetState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Cache", "NotRegistered"),
+			provider("Microsoft.Web", "Registered"),
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/EncryptionAtHost", "NotRegistered"),
+			feature("Microsoft.Compute", "Registered"),
+		},
+	}
+
+	plan := buildPlan(srcState, targetState, &planOptions{mode: "additive"})
+
+	expectedRPs := []RpRegistration{{Namespace: "Microsoft.Cache", Reason: ReasonNotRegisteredInTarget}}
+	if !reflect.DeepEqual(plan.RpRegistrations, expectedRPs) {
+		t.Errorf("RpRegistrations = %+v, expected %+v", plan.RpRegistrations, expectedRPs)
+	}
+
+	expectedFeatures := []PreviewFeature{
+		{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", Reason: ReasonNotRegisteredInTarget, Approval: ApprovalAuto},
+	}
+	if !reflect.DeepEqual(plan.PreviewFeatures, expectedFeatures) {
+		t.Errorf("PreviewFeatures = %+v, expected %+v", plan.PreviewFeatures, expectedFeatures)
+	}
+
+	expectedSkipped := []SkippedRecord{
+		{Side: "source", Kind: "resourceProvider", Problem: "missing namespace"},
+		{Side: "source", Kind: "resourceProvider", Problem: "missing namespace"},
+		{Side: "source", Kind: "previewFeature", Name: "EncryptionAtHost", Problem: "expected Namespace/Key format"},
+		{Side: "source", Kind: "previewFeature", Name: "Microsoft.Network/", Problem: "expected Namespace/Key format"},
+		{Side: "target", Kind: "previewFeature", Name: "Microsoft.Compute", Problem: "expected Namespace/Key format"},
+	}
+	if !reflect.DeepEqual(plan.SkippedRecords, expectedSkipped) {
+		t.Errorf("SkippedRecords = %+v, expected %+v", plan.SkippedRecords, expectedSkipped)
+	}
+}
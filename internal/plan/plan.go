@@ -1,17 +1,121 @@
 package plan
 
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+)
+
 type Plan struct {
+	Header          PlanHeader       `json:"header"`
 	RpRegistrations []RpRegistration `json:"rpRegistrations"`
 	PreviewFeatures []PreviewFeature `json:"previewFeatures"`
+	Drift           *Drift           `json:"drift,omitempty"`          // informational, not applied
+	Excluded        *Excluded        `json:"excluded,omitempty"`       // informational, not applied
+	SkippedRecords  []SkippedRecord  `json:"skippedRecords,omitempty"` // malformed source / target records, not planned
 }
 
 type RpRegistration struct {
-	Namespace string `json:"namespace"` // eg: "Microsoft.Cache"
-	Reason    string `json:"reason"`    // NotRegisteredInTarget | NotFoundInTarget
+	Namespace string   `json:"namespace"`         // eg: "Microsoft.Cache"
+	Action    string   `json:"action,omitempty"`  // register (default) | unregister
+	Reason    string   `json:"reason"`            // one of the Reason* constants, eg: NotRegisteredInTarget
+	Sources   []string `json:"sources,omitempty"` // sources or profiles contributing the entry
+
+	ResourceCount int `json:"resourceCount,omitempty"` // resources of the namespace in source, set in used-only mode
 }
 
 type PreviewFeature struct {
-	Key       string `json:"key"`       // eg: "Dev"
-	Namespace string `json:"namespace"` // eg: "Microsoft.DevAI"
-	Reason    string `json:"reason"`    // NotRegisteredInTarget | NotFoundInTarget
+	Key       string   `json:"key"`               // eg: "Dev"
+	Namespace string   `json:"namespace"`         // eg: "Microsoft.DevAI"
+	Action    string   `json:"action,omitempty"`  // register (default) | unregister
+	Reason    string   `json:"reason"`            // one of the Reason* constants, eg: NotRegisteredInTarget
+	Sources   []string `json:"sources,omitempty"` // sources or profiles contributing the entry
+
+	ResourceCount int `json:"resourceCount,omitempty"` // resources of the namespace in source, set in used-only mode
+
+	Approval string `json:"approval,omitempty"` // Auto | Manual, set on register entries
+
+	// *FeatureMetadata inlines the description, documentation link, approval type and dates when a live or snapshot
+	// side has a registration of the feature
+	*FeatureMetadata
+}
+
+// Drift lists RPs and preview features registered in target but not in source.
+type Drift struct {
+	ResourceProviders []RpDrift      `json:"resourceProviders"`
+	PreviewFeatures   []FeatureDrift `json:"previewFeatures"`
+}
+
+type RpDrift struct {
+	Namespace      string `json:"namespace"`      // eg: "Microsoft.Cache"
+	State          string `json:"state"`          // registration state in target, eg: "Registered"
+	Classification string `json:"classification"` // OnlyInTarget | PendingInTarget
+}
+
+type FeatureDrift struct {
+	Key            string `json:"key"`            // eg: "Dev"
+	Namespace      string `json:"namespace"`      // eg: "Microsoft.DevAI"
+	State          string `json:"state"`          // registration state in target, eg: "Pending"
+	Classification string `json:"classification"` // OnlyInTarget | PendingInTarget
+}
+
+// Excluded lists the entries deliberately left out of the plan by the include / exclude filters.
+type Excluded struct {
+	RpRegistrations []RpRegistration `json:"rpRegistrations"`
+	PreviewFeatures []PreviewFeature `json:"previewFeatures"`
+}
+
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file %s: %w", path, err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(jsonutil.StripJSONComments(data), &raw); err != nil {
+		return nil, fmt.Errorf("failed to deserialize plan from %s: %w", path, err)
+	}
+
+	if err := migratePlan(raw); err != nil {
+		return nil, fmt.Errorf("failed to load plan from %s: %w", path, err)
+	}
+
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize migrated plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(migrated, &plan); err != nil {
+		return nil, fmt.Errorf("failed to deserialize plan from %s: %w", path, err)
+	}
+
+	return &plan, nil
+}
+
+func (p *Plan) Save(path string) error {
+	contentHash, err := p.contentHash()
+	if err != nil {
+		return err
+	}
+	p.Header.SchemaVersion = CurrentPlanSchemaVersion
+	p.Header.ContentHash = contentHash
+
+	jsonData, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize plan to JSON: %w", err)
+	}
+
+	if err := os.WriteFile(path, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write plan to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// FleetPlanFileName is the plan file of one target subscription when planning a fleet.
+func FleetPlanFileName(subscriptionID string) string {
+	return "azsubsyn-plan-" + subscriptionID + ".jsonc"
 }
@@ -0,0 +1,83 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/featurename"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// planDrift lists what the target has registered, or is registering, that the source doesn't. It is the reverse of
+// the registration plan which only iterates source entries.
+func planDrift(sourceRPs []*armresources.Provider, targetRPs []*armresources.Provider,
+	srcFeatures []*armfeatures.FeatureResult, targetFeatures []*armfeatures.FeatureResult) *Drift {
+	drift := &Drift{
+		ResourceProviders: []RpDrift{},
+		PreviewFeatures:   []FeatureDrift{},
+	}
+
+	srcRPs := make(map[string]bool)
+	for _, rp := range sourceRPs {
+		if ParseRegistrationState(pointer.From(rp.RegistrationState)).IsRegisteredOrPending() {
+			srcRPs[strings.ToLower(pointer.From(rp.Namespace))] = true
+		}
+	}
+
+	for _, targetRp := range targetRPs {
+		namespace := pointer.From(targetRp.Namespace)
+		if srcRPs[strings.ToLower(namespace)] {
+			continue
+		}
+
+		state := pointer.From(targetRp.RegistrationState)
+		if classification := classifyDrift(state); classification != "" {
+			drift.ResourceProviders = append(drift.ResourceProviders, RpDrift{
+				Namespace:      namespace,
+				State:          state,
+				Classification: classification,
+			})
+		}
+	}
+
+	srcFeats := make(map[string]bool)
+	for _, feat := range srcFeatures {
+		if ParseRegistrationState(getState(feat)).IsRegisteredOrPending() {
+			srcFeats[strings.ToLower(pointer.From(feat.Name))] = true
+		}
+	}
+
+	for _, targetFeature := range targetFeatures {
+		if srcFeats[strings.ToLower(pointer.From(targetFeature.Name))] {
+			continue
+		}
+
+		state := getState(targetFeature)
+		if classification := classifyDrift(state); classification != "" {
+			key, namespace, err := featurename.Split(pointer.From(targetFeature.Name))
+			if err != nil {
+				continue
+			}
+			drift.PreviewFeatures = append(drift.PreviewFeatures, FeatureDrift{
+				Key:            key,
+				Namespace:      namespace,
+				State:          state,
+				Classification: classification,
+			})
+		}
+	}
+
+	return drift
+}
+
+func classifyDrift(targetState string) string {
+	switch ParseRegistrationState(targetState) {
+	case StateRegistered:
+		return "OnlyInTarget"
+	case StatePending, StateRegistering:
+		return "PendingInTarget"
+	default:
+		return ""
+	}
+}
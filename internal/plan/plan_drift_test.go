@@ -0,0 +1,44 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestPlanDrift(t *testing.T) {
+	sourceRPs := []*armresources.Provider{
+		provider("Microsoft.Compute", "Registered"),
+	}
+	targetRPs := []*armresources.Provider{
+		provider("Microsoft.Compute", "Registered"),
+		provider("Microsoft.Cache", "Registered"),
+		provider("Microsoft.VideoIndexer", "Registering"),
+		provider("Microsoft.Blockchain", "NotRegistered"),
+	}
+	srcFeatures := []*armfeatures.FeatureResult{
+		feature("Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "Pending"),
+	}
+	targetFeatures := []*armfeatures.FeatureResult{
+		feature("microsoft.network/AllowMultiplePeeringLinksBetweenVnets", "Registered"),
+		feature("Microsoft.Compute/EncryptionAtHost", "Pending"),
+		feature("Microsoft.DevAI/Dev", "Unregistered"),
+	}
+
+	expected := &Drift{
+		ResourceProviders: []RpDrift{
+			{Namespace: "Microsoft.Cache", State: "Registered", Classification: "OnlyInTarget"},
+			{Namespace: "Microsoft.VideoIndexer", State: "Registering", Classification: "PendingInTarget"},
+		},
+		PreviewFeatures: []FeatureDrift{
+			{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", State: "Pending", Classification: "PendingInTarget"},
+		},
+	}
+
+	actual := planDrift(sourceRPs, targetRPs, srcFeatures, targetFeatures)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("planDrift() = %+v, expected %+v", actual, expected)
+	}
+}
@@ -0,0 +1,95 @@
+package plan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gerrytan/azsubsyn/internal/config"
+)
+
+const maxConcurrentTargets = 8
+
+// fetchTargetFunc fetches the state of one target subscription, writing its progress to w.
+type fetchTargetFunc func(ctx context.Context, config *config.Config, kind string, w io.Writer) (*SubscriptionState, error)
+
+type fleetPlanResult struct {
+	config   *config.Config
+	plan     *Plan
+	planFile string
+	output   string // progress and summaries, printed once every target is planned
+	err      error
+}
+
+// planFleet plans every target against the same source concurrently and writes one plan file per target. The output of
+// each target is collected and printed in order, prefixed with its subscription ID.
+func planFleet(ctx context.Context, srcState *SubscriptionState, targetConfigs []*config.Config, opts *planOptions, fetchTarget fetchTargetFunc) error {
+	fmt.Printf("🔄 Creating plans for %d target subscriptions...\n", len(targetConfigs))
+
+	results := make([]fleetPlanResult, len(targetConfigs))
+	sem := make(chan struct{}, maxConcurrentTargets)
+	var wg sync.WaitGroup
+
+	for i, targetConfig := range targetConfigs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = planFleetTarget(ctx, srcState, targetConfig, opts, fetchTarget)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		for _, line := range strings.Split(strings.TrimSuffix(result.output, "\n"), "\n") {
+			if line != "" {
+				fmt.Printf("[%s] %s\n", result.config.SubscriptionID, line)
+			}
+		}
+	}
+
+	failed := 0
+	fmt.Println("📊 Fleet plan summary:")
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Printf("  - ❌ %s: %s\n", result.config.SubscriptionID, result.err)
+			continue
+		}
+		fmt.Printf("  - ✅ %s: %s (%d RPs, %d preview features)\n", result.config.SubscriptionID, result.planFile,
+			len(result.plan.RpRegistrations), len(result.plan.PreviewFeatures))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("❌ Failed to plan %d of %d target subscriptions", failed, len(targetConfigs))
+	}
+
+	fmt.Printf("✅ Plans written successfully for %d target subscriptions\n", len(targetConfigs))
+	return nil
+}
+
+func planFleetTarget(ctx context.Context, srcState *SubscriptionState, targetConfig *config.Config, opts *planOptions, fetchTarget fetchTargetFunc) (result fleetPlanResult) {
+	result.config = targetConfig
+	var output bytes.Buffer
+	defer func() { result.output = output.String() }()
+
+	targetState, err := fetchTarget(ctx, targetConfig, "target "+targetConfig.SubscriptionID, &output)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	targetOpts := *opts
+	targetOpts.out = &output
+	result.plan = buildPlan(srcState, targetState, &targetOpts)
+	result.planFile = FleetPlanFileName(targetConfig.SubscriptionID)
+	if result.err = result.plan.Save(result.planFile); result.err == nil {
+		printPlanSummaries(&output, result.plan, targetState.Cloud)
+	}
+	return result
+}
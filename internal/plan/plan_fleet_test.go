@@ -0,0 +1,96 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+)
+
+func TestPlanFleet(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	srcState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{provider("Microsoft.Compute", "Registered")},
+	}
+	targetStates := map[string]*SubscriptionState{
+		"sub-a": {SubscriptionID: "sub-a", ResourceProviders: []*armresources.Provider{provider("Microsoft.Compute", "NotRegistered")}},
+		"sub-b": {SubscriptionID: "sub-b", ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Compute", "Registered"),
+			provider("Microsoft.Quantum", "Registered"),
+		}},
+	}
+	fetchTarget := func(ctx context.Context, config *config.Config, kind string, w io.Writer) (*SubscriptionState, error) {
+		fmt.Fprintf(w, "🔍 Fetching %s\n", kind)
+		if state, exists := targetStates[config.SubscriptionID]; exists {
+			return state, nil
+		}
+		return nil, errors.New("subscription not found")
+	}
+
+	targetConfigs := []*config.Config{{SubscriptionID: "sub-a"}, {SubscriptionID: "sub-b"}, {SubscriptionID: "sub-missing"}}
+	if err := planFleet(context.Background(), srcState, targetConfigs, &planOptions{mode: "additive"}, fetchTarget); err == nil {
+		t.Error("planFleet() expected error for the target that couldn't be fetched")
+	}
+
+	tests := []struct {
+		subscriptionID string
+		expectedRPs    int
+		expectFile     bool
+	}{
+		{subscriptionID: "sub-a", expectedRPs: 1, expectFile: true},
+		{subscriptionID: "sub-b", expectedRPs: 0, expectFile: true},
+		{subscriptionID: "sub-missing", expectFile: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subscriptionID, func(t *testing.T) {
+			path := FleetPlanFileName(tt.subscriptionID)
+			if _, err := os.Stat(path); (err == nil) != tt.expectFile {
+				t.Fatalf("plan file %s exists = %v, expected %v", path, err == nil, tt.expectFile)
+			}
+			if !tt.expectFile {
+				return
+			}
+
+			plan, err := LoadPlan(path)
+			if err != nil {
+				t.Fatalf("LoadPlan() error = %v", err)
+			}
+			if len(plan.RpRegistrations) != tt.expectedRPs {
+				t.Errorf("RpRegistrations = %+v, expected %d entries", plan.RpRegistrations, tt.expectedRPs)
+			}
+		})
+	}
+}
+
+func TestPlanFleetTargetCollectsOutput(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	srcState := &SubscriptionState{}
+	targetState := &SubscriptionState{
+		SubscriptionID:    "sub-a",
+		ResourceProviders: []*armresources.Provider{provider("Microsoft.Quantum", "Registered")},
+	}
+	fetchTarget := func(ctx context.Context, config *config.Config, kind string, w io.Writer) (*SubscriptionState, error) {
+		fmt.Fprintf(w, "🔍 Fetching %s\n", kind)
+		return targetState, nil
+	}
+
+	result := planFleetTarget(context.Background(), srcState, &config.Config{SubscriptionID: "sub-a"}, &planOptions{mode: "additive"}, fetchTarget)
+	if result.err != nil {
+		t.Fatalf("planFleetTarget() error = %v", result.err)
+	}
+
+	for _, expected := range []string{"🔍 Fetching target sub-a", "🧭 Drift: 1 RPs"} {
+		if !strings.Contains(result.output, expected) {
+			t.Errorf("output = %q, expected it to contain %q", result.output, expected)
+		}
+	}
+}
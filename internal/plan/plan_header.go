@@ -0,0 +1,110 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// CurrentPlanSchemaVersion is the schema version of the plans written by this build, plans of older versions are
+// migrated when loaded.
+const CurrentPlanSchemaVersion = 2
+
+// PlanHeader records what produced a plan, plans written before the header existed are schema version 1.
+type PlanHeader struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	ToolVersion   string    `json:"toolVersion,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Source        PlanSide  `json:"source"`
+	Target        PlanSide  `json:"target"`
+	Mode          string    `json:"mode,omitempty"`      // additive | mirror | used-only
+	Filters       *Filter   `json:"filters,omitempty"`   // include / exclude patterns used
+	Protected     []string  `json:"protected,omitempty"` // --protect namespaces, also enforced by apply
+	ContentHash   string    `json:"contentHash"`         // "sha256:<hex>" of the RP and preview feature entries as planned
+}
+
+// PlanSide identifies one side of the plan, the subscription is empty for merged sources and required entries.
+type PlanSide struct {
+	TenantID       string `json:"tenantId,omitempty"`
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+	Origin         string `json:"origin"` // eg: "live", "snapshot source.json", "baseline baseline.jsonc"
+}
+
+func newPlanSide(state *SubscriptionState) PlanSide {
+	return PlanSide{TenantID: state.TenantID, SubscriptionID: state.SubscriptionID, Origin: state.Origin}
+}
+
+// contentHash hashes the entries applied from the plan, see EntriesEdited.
+func (p *Plan) contentHash() (string, error) {
+	data, err := json.Marshal(struct {
+		RpRegistrations []RpRegistration `json:"rpRegistrations"`
+		PreviewFeatures []PreviewFeature `json:"previewFeatures"`
+	}{p.RpRegistrations, p.PreviewFeatures})
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize plan entries: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// EntriesEdited tells whether the RP and preview feature entries differ from the ones hashed when the plan was saved,
+// plans migrated from before the header existed have no hash and are never reported as edited.
+func (p *Plan) EntriesEdited() (bool, error) {
+	if p.Header.ContentHash == "" {
+		return false, nil
+	}
+	contentHash, err := p.contentHash()
+	if err != nil {
+		return false, err
+	}
+	return contentHash != p.Header.ContentHash, nil
+}
+
+// planMigrations upgrade the raw JSON of a plan, the migration at index i turns schema version i+1 into i+2.
+var planMigrations = []func(raw map[string]any){
+	migratePlanV1ToV2,
+}
+
+// migratePlanV1ToV2 adds the header, what produced a version 1 plan is unknown.
+func migratePlanV1ToV2(raw map[string]any) {
+	raw["header"] = map[string]any{"schemaVersion": 2}
+}
+
+// planSchemaVersion returns the schema version of a raw plan, 1 when it has no header.
+func planSchemaVersion(raw map[string]any) (int, error) {
+	header, exists := raw["header"]
+	if !exists {
+		return 1, nil
+	}
+
+	fields, ok := header.(map[string]any)
+	if !ok {
+		return 0, fmt.Errorf("header is not an object")
+	}
+	version, ok := fields["schemaVersion"].(float64)
+	if !ok || version < 1 || version != float64(int(version)) {
+		return 0, fmt.Errorf("bad header schemaVersion %v", fields["schemaVersion"])
+	}
+	return int(version), nil
+}
+
+// migratePlan upgrades a raw plan to the current schema version, plans written by a newer azsubsyn are refused since
+// their entries may mean something this build doesn't know.
+func migratePlan(raw map[string]any) error {
+	version, err := planSchemaVersion(raw)
+	if err != nil {
+		return err
+	}
+	if version > CurrentPlanSchemaVersion {
+		return fmt.Errorf("plan schema version %d is newer than the version %d supported by this azsubsyn, upgrade azsubsyn to use it",
+			version, CurrentPlanSchemaVersion)
+	}
+
+	for v := version; v < CurrentPlanSchemaVersion; v++ {
+		planMigrations[v-1](raw)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPlanSchemaVersions(t *testing.T) {
+	dir := t.TempDir()
+
+	saved := &Plan{
+		Header:          PlanHeader{ToolVersion: "1.2.3", Source: PlanSide{Origin: "live"}, Target: PlanSide{Origin: "live"}},
+		RpRegistrations: []RpRegistration{{Namespace: "Microsoft.Cache", Reason: ReasonNotFoundInTarget}},
+		PreviewFeatures: []PreviewFeature{},
+	}
+	currentPath := filepath.Join(dir, "current.jsonc")
+	if err := saved.Save(currentPath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	current, err := LoadPlan(currentPath)
+	if err != nil {
+		t.Fatalf("LoadPlan() error = %v", err)
+	}
+	if current.Header.SchemaVersion != CurrentPlanSchemaVersion || current.Header.ToolVersion != "1.2.3" ||
+		!strings.HasPrefix(current.Header.ContentHash, "sha256:") {
+		t.Errorf("LoadPlan() header = %+v", current.Header)
+	}
+	if edited, err := current.EntriesEdited(); edited || err != nil {
+		t.Errorf("EntriesEdited() = %v, %v, expected false after loading", edited, err)
+	}
+	current.RpRegistrations = current.RpRegistrations[:0]
+	if edited, _ := current.EntriesEdited(); !edited {
+		t.Errorf("EntriesEdited() = false, expected true after removing an entry")
+	}
+
+	legacyPath := filepath.Join(dir, "legacy.jsonc")
+	legacy := `{
+  // written before the header existed
+  "rpRegistrations": [{ "namespace": "Microsoft.Cache", "reason": "NotFoundInTarget" }],
+  "previewFeatures": []
+}`
+	if err := os.WriteFile(legacyPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := LoadPlan(legacyPath)
+	if err != nil {
+		t.Fatalf("LoadPlan() legacy error = %v", err)
+	}
+	if migrated.Header.SchemaVersion != CurrentPlanSchemaVersion || len(migrated.RpRegistrations) != 1 {
+		t.Errorf("LoadPlan() legacy = %+v", migrated)
+	}
+	if edited, _ := migrated.EntriesEdited(); edited {
+		t.Errorf("EntriesEdited() = true, expected false without a content hash")
+	}
+
+	futurePath := filepath.Join(dir, "future.jsonc")
+	if err := os.WriteFile(futurePath, []byte(`{"header": {"schemaVersion": 99}, "rpRegistrations": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPlan(futurePath); err == nil || !strings.Contains(err.Error(), "newer than") {
+		t.Errorf("LoadPlan() future error = %v, expected newer than supported", err)
+	}
+}
@@ -8,48 +8,39 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
 	"github.com/gerrytan/azsubsyn/internal/config"
 	"github.com/gerrytan/azsubsyn/internal/credential"
+	"github.com/gerrytan/azsubsyn/internal/featurename"
 	"github.com/gerrytan/azsubsyn/internal/pointer"
 )
 
-func planPreviewFeatures(srcConfig *config.Config, targetConfig *config.Config) (prFeats []PreviewFeature, err error) {
-	ctx := context.Background()
-
-	fmt.Println("🔍 Fetching preview features from source subscription...")
-	srcFeatures, err := getPreviewFeatures(ctx, srcConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get preview features from source subscription: %w", err)
-	}
-
-	fmt.Println("🔍 Fetching preview features from target subscription...")
-	targetFeatures, err := getPreviewFeatures(ctx, targetConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get preview features from target subscription: %w", err)
-	}
-
-	// example name: "Microsoft.DevAI/Dev"
+func planPreviewFeatures(srcFeatures []*armfeatures.FeatureResult, targetFeatures []*armfeatures.FeatureResult) (prFeats []PreviewFeature) {
+	// example name: "Microsoft.DevAI/Dev", matched case-insensitively keeping the casing of the target
 	targetFeaturesByName := make(map[string]*armfeatures.FeatureResult)
 	for _, feat := range targetFeatures {
-		targetFeaturesByName[pointer.From(feat.Name)] = feat
+		targetFeaturesByName[strings.ToLower(pointer.From(feat.Name))] = feat
 	}
 
+	planned := make(map[string]bool)
 	for _, srcFeature := range srcFeatures {
-		srcState := getState(srcFeature)
-		if strings.EqualFold(srcState, "Registered") || strings.EqualFold(srcState, "Pending") {
-			targetFeature, exists := targetFeaturesByName[pointer.From(srcFeature.Name)]
-			targetState := getState(targetFeature)
+		name := strings.ToLower(pointer.From(srcFeature.Name))
+		if planned[name] {
+			continue
+		}
+		if ParseRegistrationState(getState(srcFeature)).IsRegisteredOrPending() {
+			planned[name] = true
+			targetFeature, exists := targetFeaturesByName[name]
 			if !exists {
-				srcKey, srcNamespace := parseKeyAndNamespace(srcFeature.Name)
+				srcKey, srcNamespace, _ := featurename.Split(pointer.From(srcFeature.Name))
 				prFeats = append(prFeats, PreviewFeature{
 					Key:       srcKey,
 					Namespace: srcNamespace,
-					Reason:    "NotFoundInTarget",
+					Reason:    ReasonNotFoundInTarget,
 				})
-			} else if !strings.EqualFold(targetState, "Registered") && !strings.EqualFold(targetState, "Pending") {
-				targetKey, targetNamespace := parseKeyAndNamespace(targetFeature.Name)
+			} else if reason := targetReason(ParseRegistrationState(getState(targetFeature))); reason != "" {
+				targetKey, targetNamespace, _ := featurename.Split(pointer.From(targetFeature.Name))
 				prFeats = append(prFeats, PreviewFeature{
 					Key:       targetKey,
 					Namespace: targetNamespace,
-					Reason:    "NotRegisteredInTarget",
+					Reason:    reason,
 				})
 			}
 		}
@@ -64,7 +55,7 @@ func getPreviewFeatures(ctx context.Context, config *config.Config) (features []
 		return nil, fmt.Errorf("failed to create credential: %w", err)
 	}
 
-	client, err := armfeatures.NewClient(config.SubscriptionID, cred, nil)
+	client, err := armfeatures.NewClient(config.SubscriptionID, cred, credential.ClientOptions(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create features client: %w", err)
 	}
@@ -91,16 +82,3 @@ func getState(f *armfeatures.FeatureResult) string {
 	}
 	return pointer.From(f.Properties.State)
 }
-
-func parseKeyAndNamespace(name *string) (key, namespace string) {
-	if name == nil || *name == "" {
-		panic("empty feature name")
-	}
-
-	parts := strings.SplitN(*name, "/", 2)
-	if len(parts) != 2 {
-		panic(fmt.Sprintf("bad format: %q", *name))
-	}
-
-	return parts[1], parts[0]
-}
@@ -0,0 +1,39 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+func feature(name, state string) *armfeatures.FeatureResult {
+	return &armfeatures.FeatureResult{
+		Name:       pointer.To(name),
+		Properties: &armfeatures.FeatureProperties{State: pointer.To(state)},
+	}
+}
+
+func TestPlanPreviewFeatures(t *testing.T) {
+	srcFeatures := []*armfeatures.FeatureResult{
+		feature("Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "Registered"),
+		feature("Microsoft.DBforPostgreSQL/locationCapability", "Pending"),
+		feature("Microsoft.Compute/EncryptionAtHost", "Registered"),
+		feature("Microsoft.DevAI/Dev", "NotRegistered"),
+	}
+	targetFeatures := []*armfeatures.FeatureResult{
+		feature("Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "NotRegistered"),
+		feature("Microsoft.Compute/EncryptionAtHost", "Pending"),
+	}
+
+	expected := []PreviewFeature{
+		{Key: "AllowMultiplePeeringLinksBetweenVnets", Namespace: "Microsoft.Network", Reason: "NotRegisteredInTarget"},
+		{Key: "locationCapability", Namespace: "Microsoft.DBforPostgreSQL", Reason: "NotFoundInTarget"},
+	}
+
+	actual := planPreviewFeatures(srcFeatures, targetFeatures)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("planPreviewFeatures() = %+v, expected %+v", actual, expected)
+	}
+}
@@ -11,40 +11,31 @@ import (
 	"github.com/gerrytan/azsubsyn/internal/pointer"
 )
 
-func planRPRegistrations(srcConfig *config.Config, targetConfig *config.Config) (rpRegs []RpRegistration, err error) {
-	ctx := context.Background()
-
-	fmt.Println("🔍 Fetching resource providers from source subscription...")
-	sourceRPs, err := getResourceProviders(ctx, srcConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get resource providers from source subscription: %w", err)
-	}
-
-	fmt.Println("🔍 Fetching resource providers from target subscription...")
-	targetRPs, err := getResourceProviders(ctx, targetConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get resource providers from target subscription: %w", err)
-	}
-
+func planRPRegistrations(sourceRPs []*armresources.Provider, targetRPs []*armresources.Provider) (rpRegs []RpRegistration) {
+	// namespaces are matched case-insensitively keeping the casing of the target
 	targetRPsByNamespace := make(map[string]*armresources.Provider)
 	for _, rp := range targetRPs {
-		targetRPsByNamespace[pointer.From(rp.Namespace)] = rp
+		targetRPsByNamespace[strings.ToLower(pointer.From(rp.Namespace))] = rp
 	}
 
+	planned := make(map[string]bool)
 	for _, srcRp := range sourceRPs {
-		srcRegState := pointer.From(srcRp.RegistrationState)
-		if strings.EqualFold(srcRegState, "Pending") || strings.EqualFold(srcRegState, "Registered") {
-			targetRp, exists := targetRPsByNamespace[pointer.From(srcRp.Namespace)]
+		namespace := strings.ToLower(pointer.From(srcRp.Namespace))
+		if planned[namespace] {
+			continue
+		}
+		if ParseRegistrationState(pointer.From(srcRp.RegistrationState)).IsRegisteredOrPending() {
+			planned[namespace] = true
+			targetRp, exists := targetRPsByNamespace[namespace]
 			if !exists {
 				rpRegs = append(rpRegs, RpRegistration{
 					Namespace: pointer.From(srcRp.Namespace),
-					Reason:    "NotFoundInTarget",
+					Reason:    ReasonNotFoundInTarget,
 				})
-			} else if !strings.EqualFold(pointer.From(targetRp.RegistrationState), "Pending") &&
-				!strings.EqualFold(pointer.From(targetRp.RegistrationState), "Registered") {
+			} else if reason := targetReason(ParseRegistrationState(pointer.From(targetRp.RegistrationState))); reason != "" {
 				rpRegs = append(rpRegs, RpRegistration{
 					Namespace: pointer.From(targetRp.Namespace),
-					Reason:    "NotRegisteredInTarget",
+					Reason:    reason,
 				})
 			}
 		}
@@ -59,13 +50,14 @@ func getResourceProviders(ctx context.Context, config *config.Config) (rps []*ar
 		return nil, fmt.Errorf("failed to create credential: %w", err)
 	}
 
-	client, err := armresources.NewProvidersClient(config.SubscriptionID, cred, nil)
+	client, err := armresources.NewProvidersClient(config.SubscriptionID, cred, credential.ClientOptions(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource providers client: %w", err)
 	}
 
+	// expand resource types fully so compare sees their locations, API versions and capabilities along with aliases
 	pager := client.NewListPager(&armresources.ProvidersClientListOptions{
-		Expand: nil,
+		Expand: pointer.To("resourceTypes/aliases"),
 	})
 
 	for pager.More() {
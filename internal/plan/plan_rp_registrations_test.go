@@ -0,0 +1,40 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+func provider(namespace, state string) *armresources.Provider {
+	return &armresources.Provider{
+		Namespace:         pointer.To(namespace),
+		RegistrationState: pointer.To(state),
+	}
+}
+
+func TestPlanRPRegistrations(t *testing.T) {
+	sourceRPs := []*armresources.Provider{
+		provider("Microsoft.Cache", "Registered"),
+		provider("Microsoft.VideoIndexer", "Pending"),
+		provider("Microsoft.Compute", "Registered"),
+		provider("Microsoft.Blockchain", "NotRegistered"),
+	}
+	targetRPs := []*armresources.Provider{
+		provider("Microsoft.Cache", "NotRegistered"),
+		provider("Microsoft.Compute", "Registered"),
+		provider("Microsoft.Blockchain", "NotRegistered"),
+	}
+
+	expected := []RpRegistration{
+		{Namespace: "Microsoft.Cache", Reason: "NotRegisteredInTarget"},
+		{Namespace: "Microsoft.VideoIndexer", Reason: "NotFoundInTarget"},
+	}
+
+	actual := planRPRegistrations(sourceRPs, targetRPs)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("planRPRegistrations() = %+v, expected %+v", actual, expected)
+	}
+}
@@ -0,0 +1,110 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/featurename"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// DefaultProtectedNamespaces are registered by default in every subscription and needed by ARM itself, neither the
+// RPs nor their preview features are ever unregistered.
+var DefaultProtectedNamespaces = []string{
+	"Microsoft.ADHybridHealthService",
+	"Microsoft.Authorization",
+	"Microsoft.Billing",
+	"Microsoft.ClassicSubscription",
+	"Microsoft.Commerce",
+	"Microsoft.Consumption",
+	"Microsoft.CostManagement",
+	"Microsoft.Features",
+	"Microsoft.MarketplaceOrdering",
+	"Microsoft.Portal",
+	"Microsoft.ResourceGraph",
+	"Microsoft.ResourceNotifications",
+	"Microsoft.Resources",
+	"Microsoft.SerializedObjects",
+	"Microsoft.Support",
+}
+
+func IsProtectedNamespace(namespace string, extraProtected []string) bool {
+	for _, protected := range DefaultProtectedNamespaces {
+		if strings.EqualFold(protected, namespace) {
+			return true
+		}
+	}
+	for _, protected := range extraProtected {
+		if strings.EqualFold(protected, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// planRPUnregistrations returns RPs registered in target but not in source, except protected ones which are returned
+// separately so they can be reported.
+func planRPUnregistrations(sourceRPs []*armresources.Provider, targetRPs []*armresources.Provider, extraProtected []string) (rpUnregs []RpRegistration, protected []string) {
+	srcRegistered := make(map[string]bool)
+	for _, rp := range sourceRPs {
+		if ParseRegistrationState(pointer.From(rp.RegistrationState)).IsRegisteredOrPending() {
+			srcRegistered[strings.ToLower(pointer.From(rp.Namespace))] = true
+		}
+	}
+
+	for _, targetRp := range targetRPs {
+		namespace := pointer.From(targetRp.Namespace)
+		if !ParseRegistrationState(pointer.From(targetRp.RegistrationState)).IsRegisteredOrPending() || srcRegistered[strings.ToLower(namespace)] {
+			continue
+		}
+
+		if IsProtectedNamespace(namespace, extraProtected) {
+			protected = append(protected, namespace)
+			continue
+		}
+
+		rpUnregs = append(rpUnregs, RpRegistration{
+			Namespace: namespace,
+			Action:    "unregister",
+			Reason:    ReasonNotRegisteredInSource,
+		})
+	}
+
+	return
+}
+
+// planFeatureUnregistrations returns preview features registered in target but not in source, except those of
+// protected namespaces which are returned separately so they can be reported.
+func planFeatureUnregistrations(srcFeatures []*armfeatures.FeatureResult, targetFeatures []*armfeatures.FeatureResult, extraProtected []string) (prUnregs []PreviewFeature, protected []string) {
+	srcRegistered := make(map[string]bool)
+	for _, feat := range srcFeatures {
+		if ParseRegistrationState(getState(feat)).IsRegisteredOrPending() {
+			srcRegistered[strings.ToLower(pointer.From(feat.Name))] = true
+		}
+	}
+
+	for _, targetFeature := range targetFeatures {
+		if !ParseRegistrationState(getState(targetFeature)).IsRegisteredOrPending() || srcRegistered[strings.ToLower(pointer.From(targetFeature.Name))] {
+			continue
+		}
+
+		key, namespace, err := featurename.Split(pointer.From(targetFeature.Name))
+		if err != nil {
+			continue
+		}
+		if IsProtectedNamespace(namespace, extraProtected) {
+			protected = append(protected, pointer.From(targetFeature.Name))
+			continue
+		}
+
+		prUnregs = append(prUnregs, PreviewFeature{
+			Key:       key,
+			Namespace: namespace,
+			Action:    "unregister",
+			Reason:    ReasonNotRegisteredInSource,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,61 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestPlanRPUnregistrations(t *testing.T) {
+	sourceRPs := []*armresources.Provider{
+		provider("Microsoft.Compute", "Registered"),
+		provider("Microsoft.Cache", "NotRegistered"),
+	}
+	targetRPs := []*armresources.Provider{
+		provider("microsoft.compute", "Registered"),
+		provider("Microsoft.Cache", "Registered"),
+		provider("Microsoft.VideoIndexer", "Pending"),
+		provider("Microsoft.Blockchain", "NotRegistered"),
+		provider("Microsoft.Resources", "Registered"),
+		provider("Microsoft.Insights", "Registered"),
+	}
+
+	expected := []RpRegistration{
+		{Namespace: "Microsoft.Cache", Action: "unregister", Reason: "NotRegisteredInSource"},
+		{Namespace: "Microsoft.VideoIndexer", Action: "unregister", Reason: "NotRegisteredInSource"},
+	}
+
+	actual, protected := planRPUnregistrations(sourceRPs, targetRPs, []string{"microsoft.insights"})
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("planRPUnregistrations() = %+v, expected %+v", actual, expected)
+	}
+	if expected := []string{"Microsoft.Resources", "Microsoft.Insights"}; !reflect.DeepEqual(protected, expected) {
+		t.Errorf("planRPUnregistrations() protected = %v, expected %v", protected, expected)
+	}
+}
+
+func TestPlanFeatureUnregistrations(t *testing.T) {
+	srcFeatures := []*armfeatures.FeatureResult{
+		feature("Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "Registered"),
+	}
+	targetFeatures := []*armfeatures.FeatureResult{
+		feature("Microsoft.Network/AllowMultiplePeeringLinksBetweenVnets", "Registered"),
+		feature("Microsoft.Compute/EncryptionAtHost", "Registered"),
+		feature("Microsoft.Resources/EUAPParticipation", "Registered"),
+		feature("Microsoft.DevAI/Dev", "NotRegistered"),
+	}
+
+	expected := []PreviewFeature{
+		{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", Action: "unregister", Reason: "NotRegisteredInSource"},
+	}
+
+	actual, protected := planFeatureUnregistrations(srcFeatures, targetFeatures, nil)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("planFeatureUnregistrations() = %+v, expected %+v", actual, expected)
+	}
+	if expected := []string{"Microsoft.Resources/EUAPParticipation"}; !reflect.DeepEqual(protected, expected) {
+		t.Errorf("planFeatureUnregistrations() protected = %v, expected %v", protected, expected)
+	}
+}
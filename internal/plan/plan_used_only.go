@@ -0,0 +1,44 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// usedOnlyState narrows the source down to the RPs with at least one resource and the preview features in their
+// namespaces, resourceCounts is keyed by lower-cased namespace as returned by CountResourcesByNamespace.
+func usedOnlyState(state *SubscriptionState, resourceCounts map[string]int) *SubscriptionState {
+	used := *state
+	used.ResourceProviders = []*armresources.Provider{}
+	used.PreviewFeatures = []*armfeatures.FeatureResult{}
+
+	for _, rp := range state.ResourceProviders {
+		if resourceCounts[strings.ToLower(pointer.From(rp.Namespace))] > 0 {
+			used.ResourceProviders = append(used.ResourceProviders, rp)
+		}
+	}
+
+	for _, feat := range state.PreviewFeatures {
+		namespace, _, _ := strings.Cut(pointer.From(feat.Name), "/")
+		if resourceCounts[strings.ToLower(namespace)] > 0 {
+			used.PreviewFeatures = append(used.PreviewFeatures, feat)
+		}
+	}
+
+	return &used
+}
+
+func attachRpResourceCounts(rpRegs []RpRegistration, resourceCounts map[string]int) {
+	for i := range rpRegs {
+		rpRegs[i].ResourceCount = resourceCounts[strings.ToLower(rpRegs[i].Namespace)]
+	}
+}
+
+func attachFeatureResourceCounts(prFeats []PreviewFeature, resourceCounts map[string]int) {
+	for i := range prFeats {
+		prFeats[i].ResourceCount = resourceCounts[strings.ToLower(prFeats[i].Namespace)]
+	}
+}
@@ -0,0 +1,45 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestUsedOnlyPlan(t *testing.T) {
+	srcState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Compute", "Registered"),
+			provider("Microsoft.Cache", "Registered"),
+			provider("Microsoft.Network", "Registered"),
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/EncryptionAtHost", "Registered"),
+			feature("Microsoft.Cache/Foo", "Registered"),
+		},
+		ResourceCounts: map[string]int{"microsoft.compute": 3, "microsoft.network": 1},
+	}
+	targetState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Network", "Registered"),
+		},
+	}
+
+	plan := buildPlan(srcState, targetState, &planOptions{mode: "used-only"})
+
+	expectedRPs := []RpRegistration{
+		{Namespace: "Microsoft.Compute", Reason: "NotFoundInTarget", ResourceCount: 3},
+	}
+	if !reflect.DeepEqual(plan.RpRegistrations, expectedRPs) {
+		t.Errorf("RpRegistrations = %+v, expected %+v", plan.RpRegistrations, expectedRPs)
+	}
+
+	expectedFeatures := []PreviewFeature{
+		{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", Reason: "NotFoundInTarget", ResourceCount: 3, Approval: ApprovalAuto},
+	}
+	if !reflect.DeepEqual(plan.PreviewFeatures, expectedFeatures) {
+		t.Errorf("PreviewFeatures = %+v, expected %+v", plan.PreviewFeatures, expectedFeatures)
+	}
+}
@@ -0,0 +1,44 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/profiles"
+)
+
+// loadProfileState converts the union of the profiles into a source state where each RP and preview feature is
+// required, entries list the profiles requiring them as their sources.
+func loadProfileState(names []string) (*SubscriptionState, error) {
+	union := &Baseline{}
+	rpSources := make(map[string][]string)
+	featureSources := make(map[string][]string)
+
+	for _, name := range names {
+		fmt.Printf("📂 Loading profile %s...\n", name)
+		profile, err := profiles.Load(name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, namespace := range profile.ResourceProviders {
+			key := strings.ToLower(namespace)
+			if _, exists := rpSources[key]; !exists {
+				union.ResourceProviders = append(union.ResourceProviders, namespace)
+			}
+			rpSources[key] = append(rpSources[key], profile.Name)
+		}
+		for _, feature := range profile.PreviewFeatures {
+			key := strings.ToLower(feature)
+			if _, exists := featureSources[key]; !exists {
+				union.PreviewFeatures = append(union.PreviewFeatures, feature)
+			}
+			featureSources[key] = append(featureSources[key], profile.Name)
+		}
+	}
+
+	state := union.toState("profile "+strings.Join(names, ", "), ReasonRequiredByProfile)
+	state.RpSources = rpSources
+	state.FeatureSources = featureSources
+	return state, nil
+}
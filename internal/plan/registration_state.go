@@ -0,0 +1,104 @@
+package plan
+
+import (
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// RegistrationState is the registration state of an RP or preview feature as reported by ARM, parsed
+// case-insensitively.
+type RegistrationState string
+
+const (
+	StateRegistered    RegistrationState = "Registered"
+	StateRegistering   RegistrationState = "Registering"
+	StatePending       RegistrationState = "Pending" // preview features waiting for approval
+	StateNotRegistered RegistrationState = "NotRegistered"
+	StateUnregistering RegistrationState = "Unregistering"
+	StateUnregistered  RegistrationState = "Unregistered"
+)
+
+var registrationStates = []RegistrationState{
+	StateRegistered, StateRegistering, StatePending, StateNotRegistered, StateUnregistering, StateUnregistered,
+}
+
+// ParseRegistrationState returns the known state matching case-insensitively, an unknown state is returned as is and
+// an empty one as NotRegistered.
+func ParseRegistrationState(state string) RegistrationState {
+	if state == "" {
+		return StateNotRegistered
+	}
+	for _, known := range registrationStates {
+		if strings.EqualFold(state, string(known)) {
+			return known
+		}
+	}
+	return RegistrationState(state)
+}
+
+// IsRegisteredOrPending tells whether the registration is done or only waiting for approval, both count as registered
+// when comparing source and target.
+func (s RegistrationState) IsRegisteredOrPending() bool {
+	return s == StateRegistered || s == StatePending
+}
+
+// Reasons of plan entries.
+const (
+	ReasonNotRegisteredInTarget    = "NotRegisteredInTarget"
+	ReasonNotFoundInTarget         = "NotFoundInTarget"
+	ReasonUnregisteringInTarget    = "UnregisteringInTarget"    // apply waits for the unregistration to finish, then registers
+	ReasonStuckRegisteringInTarget = "StuckRegisteringInTarget" // apply waits for the registration, re-triggers it when it doesn't complete
+	ReasonPendingInSource          = "PendingInSource"          // set with `plan --pending-in-source`, skipped by apply
+	ReasonUnavailableInTargetCloud = "UnavailableInTargetCloud" // skipped by apply
+	ReasonNotRegisteredInSource    = "NotRegisteredInSource"    // unregister entries in mirror mode
+
+	ReasonRequiredByBaseline  = "RequiredByBaseline"
+	ReasonRequiredByTemplate  = "RequiredByTemplate"
+	ReasonRequiredByTerraform = "RequiredByTerraform"
+	ReasonRequiredByProfile   = "RequiredByProfile"
+)
+
+// targetReason returns the reason to register an entry given its state in the target, or "" when the target already
+// has it registered or pending.
+func targetReason(targetState RegistrationState) string {
+	switch targetState {
+	case StateRegistered, StatePending:
+		return ""
+	case StateUnregistering:
+		return ReasonUnregisteringInTarget
+	case StateRegistering:
+		return ReasonStuckRegisteringInTarget
+	default:
+		return ReasonNotRegisteredInTarget
+	}
+}
+
+// markPendingInSource reports the registration entries still pending in source as PendingInSource, they are planned
+// so the reviewer sees them but apply waits for the source to complete before registering them in target.
+func markPendingInSource(plan *Plan, srcState *SubscriptionState) {
+	pendingRPs := make(map[string]bool)
+	for _, rp := range srcState.ResourceProviders {
+		if ParseRegistrationState(pointer.From(rp.RegistrationState)) == StatePending {
+			pendingRPs[strings.ToLower(pointer.From(rp.Namespace))] = true
+		}
+	}
+	pendingFeatures := make(map[string]bool)
+	for _, feat := range srcState.PreviewFeatures {
+		if ParseRegistrationState(getState(feat)) == StatePending {
+			pendingFeatures[strings.ToLower(pointer.From(feat.Name))] = true
+		}
+	}
+
+	for i := range plan.RpRegistrations {
+		if plan.RpRegistrations[i].Reason != ReasonUnavailableInTargetCloud && pendingRPs[strings.ToLower(plan.RpRegistrations[i].Namespace)] {
+			plan.RpRegistrations[i].Reason = ReasonPendingInSource
+		}
+	}
+	for i := range plan.PreviewFeatures {
+		feat := &plan.PreviewFeatures[i]
+		if feat.Reason != ReasonUnavailableInTargetCloud && pendingFeatures[strings.ToLower(feat.Namespace+"/"+feat.Key)] {
+			feat.Reason = ReasonPendingInSource
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestParseRegistrationState(t *testing.T) {
+	tests := map[string]RegistrationState{
+		"registered":    StateRegistered,
+		"UNREGISTERING": StateUnregistering,
+		"":              StateNotRegistered,
+		"Moving":        RegistrationState("Moving"),
+	}
+	for input, expected := range tests {
+		if actual := ParseRegistrationState(input); actual != expected {
+			t.Errorf("ParseRegistrationState(%q) = %q, expected %q", input, actual, expected)
+		}
+	}
+}
+
+func TestPlanReasonsByTargetState(t *testing.T) {
+	srcState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Compute", "Registered"),
+			provider("Microsoft.Network", "Registered"),
+			provider("Microsoft.Cache", "Registered"),
+			provider("Microsoft.Web", "Registered"),
+			provider("Microsoft.Quantum", "Pending"),
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/EncryptionAtHost", "Registered"),
+			feature("Microsoft.Network/AllowGatewayLoadBalancer", "Pending"),
+		},
+	}
+	targetState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Compute", "Unregistering"),
+			provider("Microsoft.Network", "Registering"),
+			provider("Microsoft.Cache", "Unregistered"),
+			provider("Microsoft.Web", "Registered"),
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/EncryptionAtHost", "Registering"),
+		},
+	}
+
+	plan := buildPlan(srcState, targetState, &planOptions{mode: "additive", pendingInSource: true})
+
+	expectedRPs := []RpRegistration{
+		{Namespace: "Microsoft.Compute", Reason: ReasonUnregisteringInTarget},
+		{Namespace: "Microsoft.Network", Reason: ReasonStuckRegisteringInTarget},
+		{Namespace: "Microsoft.Cache", Reason: ReasonNotRegisteredInTarget},
+		{Namespace: "Microsoft.Quantum", Reason: ReasonPendingInSource},
+	}
+	if !reflect.DeepEqual(plan.RpRegistrations, expectedRPs) {
+		t.Errorf("RpRegistrations = %+v, expected %+v", plan.RpRegistrations, expectedRPs)
+	}
+
+	expectedFeatures := []PreviewFeature{
+		{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", Reason: ReasonStuckRegisteringInTarget, Approval: ApprovalAuto},
+		{Key: "AllowGatewayLoadBalancer", Namespace: "Microsoft.Network", Reason: ReasonPendingInSource, Approval: ApprovalManual},
+	}
+	if !reflect.DeepEqual(plan.PreviewFeatures, expectedFeatures) {
+		t.Errorf("PreviewFeatures = %+v, expected %+v", plan.PreviewFeatures, expectedFeatures)
+	}
+}
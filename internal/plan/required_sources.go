@@ -0,0 +1,70 @@
+package plan
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/gerrytan/azsubsyn/internal/flagutil"
+)
+
+// requiredSources holds the source flags declaring required RPs and preview features instead of reading them from a
+// subscription. Only one kind can be given per plan.
+type requiredSources struct {
+	baseline          string
+	templates         flagutil.StringList
+	terraformDirs     flagutil.StringList
+	terraformMappings flagutil.StringList
+	profiles          flagutil.StringList
+}
+
+func (r *requiredSources) registerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&r.baseline, "baseline", "", "")
+	fs.Var(&r.templates, "from-template", "")
+	fs.Var(&r.terraformDirs, "from-terraform", "")
+	fs.Var(&r.terraformMappings, "terraform-mapping", "")
+	fs.Var(&r.profiles, "profile", "")
+}
+
+func (r *requiredSources) kinds() (kinds []string) {
+	if r.baseline != "" {
+		kinds = append(kinds, "--baseline")
+	}
+	if len(r.templates) > 0 {
+		kinds = append(kinds, "--from-template")
+	}
+	if len(r.terraformDirs) > 0 {
+		kinds = append(kinds, "--from-terraform")
+	}
+	if len(r.profiles) > 0 {
+		kinds = append(kinds, "--profile")
+	}
+	return
+}
+
+func (r *requiredSources) isSet() bool {
+	return len(r.kinds()) > 0
+}
+
+func (r *requiredSources) validate(srcSource *StateSource) error {
+	kinds := r.kinds()
+	if len(kinds) > 1 || (len(kinds) == 1 && srcSource.IsSet()) {
+		return fmt.Errorf("%s can't be combined with other source options", kinds[0])
+	}
+	if len(r.terraformMappings) > 0 && len(r.terraformDirs) == 0 {
+		return fmt.Errorf("--terraform-mapping requires --from-terraform")
+	}
+	return nil
+}
+
+func (r *requiredSources) resolve() (*SubscriptionState, error) {
+	switch {
+	case r.baseline != "":
+		return loadBaselineState(r.baseline)
+	case len(r.templates) > 0:
+		return loadTemplateState(r.templates)
+	case len(r.profiles) > 0:
+		return loadProfileState(r.profiles)
+	default:
+		return loadTerraformState(r.terraformDirs, r.terraformMappings)
+	}
+}
@@ -0,0 +1,53 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/credential"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// CountResourcesByNamespace lists every resource in the subscription and counts them by the provider namespace in
+// their resource ID, keyed by lower-cased namespace.
+func CountResourcesByNamespace(ctx context.Context, config *config.Config) (map[string]int, error) {
+	cred, err := credential.BuildCredential(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	client, err := armresources.NewClient(config.SubscriptionID, cred, credential.ClientOptions(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resources client: %w", err)
+	}
+
+	counts := make(map[string]int)
+	pager := client.NewListPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resources page: %w", err)
+		}
+
+		for _, resource := range page.Value {
+			if namespace := namespaceFromResourceID(pointer.From(resource.ID)); namespace != "" {
+				counts[strings.ToLower(namespace)]++
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+// example ID: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm1"
+func namespaceFromResourceID(id string) string {
+	resourceID, err := arm.ParseResourceID(id)
+	if err != nil {
+		return ""
+	}
+	return resourceID.ResourceType.Namespace
+}
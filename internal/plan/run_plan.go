@@ -1,72 +1,425 @@
 package plan
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/flagutil"
+	"github.com/gerrytan/azsubsyn/internal/targets"
 )
 
-func RunPlan() error {
-	if len(os.Args) > 2 {
+// planOptions are the plan flags changing how entries are derived from the source and target states.
+type planOptions struct {
+	mode            string // additive | mirror | used-only
+	protected       flagutil.StringList
+	filter          Filter
+	pendingInSource bool
+	toolVersion     string
+
+	cloudMappings cloudMappings
+	out           io.Writer // progress and summaries, stdout when nil
+}
+
+func (o *planOptions) output() io.Writer {
+	if o.out == nil {
+		return os.Stdout
+	}
+	return o.out
+}
+
+func RunPlan(toolVersion string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	fs.Usage = printUsage
+
+	var srcSource, targetSource StateSource
+	var targetSelection targets.Selection
+	var required requiredSources
+	var merge, filterFile string
+	opts := planOptions{toolVersion: toolVersion}
+	var include, exclude, cloudMappingFiles flagutil.StringList
+	srcSource.RegisterFlags(fs, "source")
+	srcSource.registerFleetFlag(fs)
+	targetSource.RegisterFlags(fs, "target")
+	targetSelection.RegisterFlags(fs)
+	required.registerFlags(fs)
+	fs.StringVar(&merge, "merge", "union", "")
+	fs.StringVar(&opts.mode, "mode", "additive", "")
+	fs.Var(&opts.protected, "protect", "")
+	fs.Var(&include, "include", "")
+	fs.Var(&exclude, "exclude", "")
+	fs.StringVar(&filterFile, "filter-file", "", "")
+	fs.Var(&cloudMappingFiles, "cloud-mapping", "")
+	fs.BoolVar(&opts.pendingInSource, "pending-in-source", false, "")
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+
+	if fs.NArg() > 0 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	srcConfig, targetConfig, err := config.BuildConfigs()
-	if err != nil {
-		return fmt.Errorf("❌ Failed to build configurations: %w", err)
+	if err := required.validate(&srcSource); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+	if err := srcSource.Validate(true); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+	if err := targetSource.Validate(false); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+	if targetSelection.IsSet() && targetSource.IsSet() {
+		return fmt.Errorf("❌ Multiple target selection can't be combined with other target options")
 	}
 
-	fmt.Printf("🔄 Creating plan from source and target subscription...\n")
-	fmt.Printf("  - Source tenant / sub: %s / %s\n", srcConfig.TenantID, srcConfig.SubscriptionID)
-	fmt.Printf("  - Target tenant / sub: %s / %s\n", targetConfig.TenantID, targetConfig.SubscriptionID)
+	if opts.mode != "additive" && opts.mode != "mirror" && opts.mode != "used-only" {
+		return fmt.Errorf("❌ Unknown mode %q, expected additive | mirror | used-only", opts.mode)
+	}
+	if opts.mode == "used-only" && (required.isSet() || srcSource.IsSet()) {
+		return fmt.Errorf("❌ --mode used-only requires the live source subscription to count its resources")
+	}
+	// required entries list what must be registered, not everything the target may keep, mirroring them would
+	// unregister the rest of the target
+	if opts.mode == "mirror" && required.isSet() {
+		return fmt.Errorf("❌ --mode mirror requires source subscription states, it can't be combined with required RP sources")
+	}
 
-	plan := Plan{}
+	if filterFile != "" {
+		filter, err := LoadFilter(filterFile)
+		if err != nil {
+			return fmt.Errorf("❌ %w", err)
+		}
+		opts.filter = *filter
+	}
+	opts.filter.Include = append(opts.filter.Include, include...)
+	opts.filter.Exclude = append(opts.filter.Exclude, exclude...)
+	if err := opts.filter.validate(); err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
 
-	fmt.Println("📋 Creating RP registration plan...")
+	cloudMappings, err := loadCloudMappings(cloudMappingFiles)
+	if err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+	opts.cloudMappings = cloudMappings
 
-	rpRegs, err := planRPRegistrations(srcConfig, targetConfig)
+	strategy, err := parseMergeStrategy(merge)
 	if err != nil {
-		return fmt.Errorf("❌ Failed to plan RP registrations: %w", err)
+		return fmt.Errorf("❌ %w", err)
 	}
-	plan.RpRegistrations = rpRegs
 
-	fmt.Println("📋 Creating preview features plan...")
+	ctx := context.Background()
 
-	previewFeatures, err := planPreviewFeatures(srcConfig, targetConfig)
+	srcState, err := resolveSourceState(ctx, &srcSource, &required, strategy)
 	if err != nil {
-		return fmt.Errorf("❌ Failed to plan preview features: %w", err)
+		return fmt.Errorf("❌ Failed to get source subscription state: %w", err)
 	}
-	plan.PreviewFeatures = previewFeatures
 
-	jsonData, err := json.MarshalIndent(plan, "", "  ")
-	if err != nil {
-		return fmt.Errorf("❌ Failed to serialize plan to JSON: %w", err)
+	if opts.mode == "used-only" {
+		srcConfig, err := config.BuildConfig("src")
+		if err != nil {
+			return fmt.Errorf("❌ Failed to build source configuration: %w", err)
+		}
+
+		fmt.Println("🔍 Counting resources in source subscription...")
+		srcState.ResourceCounts, err = CountResourcesByNamespace(ctx, srcConfig)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to count resources in source subscription: %w", err)
+		}
 	}
 
-	err = os.WriteFile("azsubsyn-plan.jsonc", jsonData, 0644)
+	if targetSelection.IsSet() {
+		targetConfigs, err := targetSelection.Resolve(ctx)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to resolve target subscriptions: %w", err)
+		}
+		return planFleet(ctx, srcState, targetConfigs, &opts, fetchStateTo)
+	}
+
+	targetStates, err := targetSource.Resolve(ctx, "target", "target")
 	if err != nil {
-		return fmt.Errorf("❌ Failed to write plan to file: %w", err)
+		return fmt.Errorf("❌ Failed to get target subscription state: %w", err)
+	}
+	targetState := targetStates[0]
+
+	fmt.Printf("🔄 Creating plan from source and target subscription...\n")
+	fmt.Printf("  - Source tenant / sub: %s\n", srcState.describe())
+	fmt.Printf("  - Target tenant / sub: %s\n", targetState.describe())
+
+	fmt.Println("📋 Creating RP registration and preview features plan...")
+	plan := buildPlan(srcState, targetState, &opts)
+
+	if err := plan.Save("azsubsyn-plan.jsonc"); err != nil {
+		return fmt.Errorf("❌ Failed to save plan: %w", err)
 	}
 
+	printPlanSummaries(os.Stdout, plan, targetState.Cloud)
+
 	fmt.Printf("✅ Plan written successfully to azsubsyn-plan.jsonc (%d RPs, %d preview features)\n", len(plan.RpRegistrations), len(plan.PreviewFeatures))
 	return nil
 }
 
+// printPlanSummaries prints what the reviewer should know about a plan besides its entries.
+func printPlanSummaries(w io.Writer, plan *Plan, targetCloud string) {
+	printUnavailableSummary(w, plan, targetCloud)
+	printDriftSummary(w, plan.Drift)
+	printExcludedSummary(w, plan.Excluded)
+	printApprovalSummary(w, plan)
+	printSkippedSummary(w, plan.SkippedRecords)
+}
+
+func printUnavailableSummary(w io.Writer, plan *Plan, targetCloud string) {
+	var rps, features []string
+	for _, rpReg := range plan.RpRegistrations {
+		if rpReg.Reason == ReasonUnavailableInTargetCloud {
+			rps = append(rps, rpReg.Namespace)
+		}
+	}
+	for _, feat := range plan.PreviewFeatures {
+		if feat.Reason == ReasonUnavailableInTargetCloud {
+			features = append(features, feat.Namespace+"/"+feat.Key)
+		}
+	}
+	if len(rps)+len(features) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "☁️  %d RPs and %d preview features don't exist in the %s cloud, they are skipped at apply\n", len(rps), len(features), targetCloud)
+	for _, namespace := range rps {
+		fmt.Fprintf(w, "  - RP %s\n", namespace)
+	}
+	for _, name := range features {
+		fmt.Fprintf(w, "  - Preview feature %s\n", name)
+	}
+}
+
+func printDriftSummary(w io.Writer, drift *Drift) {
+	if len(drift.ResourceProviders)+len(drift.PreviewFeatures) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "🧭 Drift: %d RPs and %d preview features are registered in target but not in source\n",
+		len(drift.ResourceProviders), len(drift.PreviewFeatures))
+	for _, rp := range drift.ResourceProviders {
+		fmt.Fprintf(w, "  - RP %s: %s (%s)\n", rp.Namespace, rp.Classification, rp.State)
+	}
+	for _, feat := range drift.PreviewFeatures {
+		fmt.Fprintf(w, "  - Preview feature %s/%s: %s (%s)\n", feat.Namespace, feat.Key, feat.Classification, feat.State)
+	}
+}
+
+func printExcludedSummary(w io.Writer, excluded *Excluded) {
+	if excluded == nil || len(excluded.RpRegistrations)+len(excluded.PreviewFeatures) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "🚫 Excluded by filter: %d RPs and %d preview features\n", len(excluded.RpRegistrations), len(excluded.PreviewFeatures))
+	for _, rp := range excluded.RpRegistrations {
+		fmt.Fprintf(w, "  - RP %s (Reason: %s)\n", rp.Namespace, rp.Reason)
+	}
+	for _, feat := range excluded.PreviewFeatures {
+		fmt.Fprintf(w, "  - Preview feature %s/%s (Reason: %s)\n", feat.Namespace, feat.Key, feat.Reason)
+	}
+}
+
+// resolveSourceState returns the required RPs and preview features if given, otherwise the source subscriptions merged
+// using the strategy.
+func resolveSourceState(ctx context.Context, srcSource *StateSource, required *requiredSources, strategy *mergeStrategy) (*SubscriptionState, error) {
+	if required.isSet() {
+		return required.resolve()
+	}
+
+	states, err := srcSource.Resolve(ctx, "src", "source")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(states) == 1 {
+		return states[0], nil
+	}
+
+	fmt.Printf("🔀 Merging %d source subscriptions using %s...\n", len(states), strategy.name)
+	for _, state := range states {
+		fmt.Printf("  - %s\n", state.describe())
+	}
+	return mergeStates(states, strategy)
+}
+
+func buildPlan(srcState *SubscriptionState, targetState *SubscriptionState, opts *planOptions) *Plan {
+	plan := &Plan{
+		Header: PlanHeader{
+			ToolVersion: opts.toolVersion,
+			CreatedAt:   time.Now().UTC(),
+			Source:      newPlanSide(srcState),
+			Target:      newPlanSide(targetState),
+			Mode:        opts.mode,
+		},
+	}
+	if opts.filter.isSet() {
+		filter := opts.filter
+		plan.Header.Filters = &filter
+	}
+	if len(opts.protected) > 0 {
+		plan.Header.Protected = append([]string{}, opts.protected...)
+	}
+
+	// malformed records are reported rather than failing the whole plan
+	srcState, srcSkipped := sanitizeState(srcState, "source")
+	targetState, targetSkipped := sanitizeState(targetState, "target")
+	plan.SkippedRecords = append(srcSkipped, targetSkipped...)
+
+	// in used-only mode the source is narrowed down to the namespaces with resources, drift still compares the full
+	// source so RPs registered in both aren't reported
+	usedState := srcState
+	if opts.mode == "used-only" {
+		usedState = usedOnlyState(srcState, srcState.ResourceCounts)
+	}
+
+	// across clouds the source entries are renamed to their target cloud names first
+	pair, crossCloud := crossCloudPair(srcState, targetState)
+	var unavailableRPs, unavailableFeatures []string
+	if crossCloud {
+		mapping := opts.cloudMappings.get(pair)
+		usedState, unavailableRPs, unavailableFeatures = mapping.mapToCloud(usedState)
+		srcState, _, _ = mapping.mapToCloud(srcState)
+	}
+
+	plan.RpRegistrations = planRPRegistrations(usedState.ResourceProviders, targetState.ResourceProviders)
+	plan.PreviewFeatures = planPreviewFeatures(usedState.PreviewFeatures, targetState.PreviewFeatures)
+	if crossCloud {
+		markUnavailableInTargetCloud(plan, unavailableRPs, unavailableFeatures)
+	}
+	if opts.pendingInSource {
+		markPendingInSource(plan, usedState)
+	}
+
+	if srcState.RequiredReason != "" {
+		overrideRpReasons(plan.RpRegistrations, srcState.RequiredReason)
+		overrideFeatureReasons(plan.PreviewFeatures, srcState.RequiredReason)
+	}
+	if srcState.RpSources != nil {
+		attachRpSources(plan.RpRegistrations, srcState.RpSources)
+	}
+	if srcState.FeatureSources != nil {
+		attachFeatureSources(plan.PreviewFeatures, srcState.FeatureSources)
+	}
+	if opts.mode == "used-only" {
+		attachRpResourceCounts(plan.RpRegistrations, srcState.ResourceCounts)
+		attachFeatureResourceCounts(plan.PreviewFeatures, srcState.ResourceCounts)
+	}
+
+	plan.Drift = planDrift(srcState.ResourceProviders, targetState.ResourceProviders, srcState.PreviewFeatures, targetState.PreviewFeatures)
+
+	if opts.mode == "mirror" {
+		rpUnregs, protectedRPs := planRPUnregistrations(srcState.ResourceProviders, targetState.ResourceProviders, opts.protected)
+		plan.RpRegistrations = append(plan.RpRegistrations, rpUnregs...)
+
+		prUnregs, protectedFeatures := planFeatureUnregistrations(srcState.PreviewFeatures, targetState.PreviewFeatures, opts.protected)
+		plan.PreviewFeatures = append(plan.PreviewFeatures, prUnregs...)
+
+		for _, namespace := range protectedRPs {
+			fmt.Fprintf(opts.output(), "  - 🛡️  Not unregistering protected RP %s in %s\n", namespace, targetState.SubscriptionID)
+		}
+		for _, name := range protectedFeatures {
+			fmt.Fprintf(opts.output(), "  - 🛡️  Not unregistering preview feature %s of a protected namespace in %s\n", name, targetState.SubscriptionID)
+		}
+	}
+
+	attachFeatureMetadata(plan.PreviewFeatures, srcState.FeatureMetadata, targetState.FeatureMetadata)
+	classifyApproval(plan.PreviewFeatures, srcState.PreviewFeatures)
+
+	if opts.filter.isSet() {
+		filterPlan(plan, &opts.filter)
+	}
+
+	return plan
+}
+
 func printUsage() {
 	fmt.Println("azsubsyn plan - Scan unregistered RPs and preview feature in the target subscription and save the plan to a file")
 	fmt.Println()
 	fmt.Println("USAGE:")
-	fmt.Println("  azsubsyn plan")
+	fmt.Println("  azsubsyn plan [<source options>] [<target options>]")
+	fmt.Println()
+	fmt.Println("SOURCE OPTIONS:")
+	fmt.Println("  --source-snapshot <file>        Read the source state from a file created by `azsubsyn snapshot`, repeatable")
+	fmt.Println("  --source-az-providers <file>    Read the source RPs from `az provider list -o json` output, repeatable, each")
+	fmt.Println("                                  paired with a --source-az-features")
+	fmt.Println("  --source-az-features <file>     Read the source preview features from `az feature list -o json` output")
+	fmt.Println("  --source-fleet <file>           Read every live subscription listed under \"sources\" in a fleet file")
+	fmt.Println("  --merge <strategy>              How multiple sources are merged: union (default) registers what any source has,")
+	fmt.Println("                                  intersection what all sources have, quorum=N what at least N sources have")
+	fmt.Println("  --baseline <file>               Read the required RPs and preview features from a baseline file")
+	fmt.Println("  --from-template <file>          Require the RP of every resource type deployed by an ARM JSON template, repeatable.")
+	fmt.Println("                                  Inline nested deployments and linked templates with a relativePath are followed")
+	fmt.Println("  --profile <name>|<file>         Require the RPs and preview features of a scenario profile, eg: aks, repeatable.")
+	fmt.Println("                                  See `azsubsyn profiles list`")
+	fmt.Println("  --from-terraform <dir>          Require the RP of every azurerm_* and azapi_resource declared in the .tf files of a")
+	fmt.Println("                                  directory and its sub-directories, repeatable")
+	fmt.Println("  --terraform-mapping <file>      JSONC file mapping azurerm resource types to RP namespaces on top of the built-in")
+	fmt.Println("                                  mapping, repeatable, eg: {\"azurerm_foo_*\": \"Microsoft.Foo\"}")
+	fmt.Println()
+	fmt.Println("PLAN OPTIONS:")
+	fmt.Println("  --cloud-mapping <file>          JSONC file renaming or ignoring RPs and preview features per cloud pair on top of the")
+	fmt.Println("                                  built-in mapping, repeatable, see README")
+	fmt.Println("  --mode <mode>                   additive (default) only registers, mirror also unregisters RPs and preview features")
+	fmt.Println("                                  registered in target but not in source and can't be combined with required RP")
+	fmt.Println("                                  sources, used-only only registers RPs with at least one resource in the live source")
+	fmt.Println("                                  and the preview features of their namespaces")
+	fmt.Println("  --protect <namespace>           Never unregister this RP or its preview features in mirror mode, repeatable. Core")
+	fmt.Println("                                  namespaces such as Microsoft.Resources and Microsoft.Authorization are always protected")
+	fmt.Println("  --pending-in-source             Report entries only pending in source with the PendingInSource reason, apply skips")
+	fmt.Println("                                  them until the source registration completes and the plan is re-created")
+	fmt.Println("  --include <glob>                Only plan matching RPs and preview features, repeatable, eg: 'Microsoft.ContainerService/*'")
+	fmt.Println("  --exclude <glob>                Don't plan matching RPs and preview features, repeatable, eg: 'Microsoft.Classic*'")
+	fmt.Println("  --filter-file <file>            Read include / exclude patterns from a JSONC file: {\"include\": [], \"exclude\": []}")
+	fmt.Println()
+	fmt.Println("TARGET OPTIONS:")
+	fmt.Println("  --target-snapshot <file>        Read the target state from a file created by `azsubsyn snapshot`")
+	fmt.Println("  --target-az-providers <file>    Read the target RPs from `az provider list -o json` output, requires --target-az-features")
+	fmt.Println("  --target-az-features <file>     Read the target preview features from `az feature list -o json` output")
+	fmt.Println("  --fleet <file>                  Plan every target subscription listed in a fleet file, one plan file per target")
+	fmt.Println("  --target-management-group <id>  Plan every active subscription under a management group, including nested groups")
+	fmt.Println("  --target-name <glob>            Plan every active subscription whose display name matches, eg: 'prod-*'")
+	fmt.Println("  --target-name-regex <regex>     Plan every active subscription whose display name matches the regular expression")
+	fmt.Println("  --target-tag <key>[=<value>]    Plan every active subscription with the tag, repeatable, eg: env=prod")
 	fmt.Println()
 	fmt.Println("DESCRIPTION:")
 	fmt.Println("  Fetch RP and preview features registrations for both source and target subscriptions and creates a")
 	fmt.Println("  modification plan to be applied to the target subscription. The plan is saved to `azsubsyn-plan.jsonc` file in the working")
 	fmt.Println("  directory.")
 	fmt.Println()
-	fmt.Println("  The modification is always additive, if target subscription already has an RP / feature registered, it won't be turned off.")
+	fmt.Println("  Each side is read from the live subscription unless one of its options is given. Credentials are only")
+	fmt.Println("  required for the sides that are read live.")
+	fmt.Println()
+	fmt.Println("  With multiple targets they are fetched concurrently and each plan is saved to `azsubsyn-plan-<subscription-id>.jsonc`.")
+	fmt.Println("  Management group, name and tag selection use the AZSUBSYN_TARGET_* service principal for every subscription it")
+	fmt.Println("  can see, AZSUBSYN_TARGET_SUBSCRIPTION_ID is not required. Name and tag filters narrow a management group down.")
+	fmt.Println()
+	fmt.Println("  The modification is additive by default, if target subscription already has an RP / feature registered, it won't be")
+	fmt.Println("  turned off. With --mode mirror the plan also contains unregister entries, which `azsubsyn apply` only executes when")
+	fmt.Println("  given --allow-unregister.")
+	fmt.Println()
+	fmt.Println("  The plan file also has an informational `drift` section listing RPs and preview features registered in target but")
+	fmt.Println("  not in source, classified as OnlyInTarget or PendingInTarget. It is never applied.")
+	fmt.Println()
+	fmt.Println("  Patterns without a slash match the namespace of RPs and preview features, patterns with a slash match the")
+	fmt.Println("  Namespace/Key of preview features. Entries left out by the filters are listed in the `excluded` section.")
+	fmt.Println()
+	fmt.Println("  Each side uses Azure Public unless AZSUBSYN_SRC_CLOUD / AZSUBSYN_TARGET_CLOUD is set to usgovernment or china.")
+	fmt.Println("  Across clouds, entries that don't exist in the target cloud get the UnavailableInTargetCloud reason and are")
+	fmt.Println("  skipped by `azsubsyn apply`.")
 	fmt.Println()
 	fmt.Println("  The plan file can be modified manually if necessary.")
 }
@@ -0,0 +1,75 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gerrytan/azsubsyn/internal/config"
+)
+
+func RunSnapshot(toolVersion string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	fs.Usage = printSnapshotUsage
+
+	var side, output string
+	fs.StringVar(&side, "side", "", "")
+	fs.StringVar(&output, "o", "", "")
+	fs.StringVar(&output, "output", "", "")
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(1)
+	}
+
+	if (side != "src" && side != "target") || fs.NArg() > 0 {
+		printSnapshotUsage()
+		os.Exit(1)
+	}
+
+	if output == "" {
+		output = "azsubsyn-snapshot-" + side + ".json"
+	}
+
+	config, err := config.BuildConfig(side)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to build configuration: %w", err)
+	}
+
+	fmt.Printf("📸 Capturing %s subscription state...\n", side)
+	fmt.Printf("  - Tenant ID: %s\n", config.TenantID)
+	fmt.Printf("  - Subscription ID: %s\n", config.SubscriptionID)
+
+	snapshot, err := captureSnapshot(context.Background(), config, side, toolVersion)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to capture snapshot: %w", err)
+	}
+
+	if err := snapshot.Save(output); err != nil {
+		return fmt.Errorf("❌ Failed to save snapshot: %w", err)
+	}
+
+	fmt.Printf("✅ Snapshot written successfully to %s (%d RPs, %d preview features)\n", output, len(snapshot.ResourceProviders), len(snapshot.PreviewFeatures))
+	return nil
+}
+
+func printSnapshotUsage() {
+	fmt.Println("azsubsyn snapshot - Capture RP and preview feature registrations of a subscription to a file")
+	fmt.Println()
+	fmt.Println("USAGE:")
+	fmt.Println("  azsubsyn snapshot --side src|target [-o <snapshot-file>]")
+	fmt.Println()
+	fmt.Println("OPTIONS:")
+	fmt.Println("  --side          Subscription to capture, src or target. Only the credentials of that side are required")
+	fmt.Println("  -o, --output    Snapshot file to write (default: azsubsyn-snapshot-<side>.json)")
+	fmt.Println()
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  Saves the full resource provider list (namespace, registration state and policy, resource types) and the")
+	fmt.Println("  full preview feature list along with the subscription ID, tenant ID, capture time and tool version.")
+	fmt.Println("  The snapshot can be kept as an audit artifact or used as a baseline without re-querying ARM.")
+}
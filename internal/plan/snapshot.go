@@ -0,0 +1,70 @@
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+)
+
+// Snapshot is the full RP and preview feature registration state of a subscription at a point in time.
+type Snapshot struct {
+	SubscriptionID    string                       `json:"subscriptionId"`
+	TenantID          string                       `json:"tenantId"`
+	Cloud             string                       `json:"cloud,omitempty"`
+	CapturedAt        time.Time                    `json:"capturedAt"`
+	ToolVersion       string                       `json:"toolVersion"`
+	ResourceProviders []*armresources.Provider     `json:"resourceProviders"`
+	PreviewFeatures   []*armfeatures.FeatureResult `json:"previewFeatures"`
+	FeatureMetadata   map[string]*FeatureMetadata  `json:"featureMetadata,omitempty"` // keyed by lower-cased "Namespace/Key"
+}
+
+func captureSnapshot(ctx context.Context, config *config.Config, kind string, toolVersion string) (*Snapshot, error) {
+	state, err := fetchState(ctx, config, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		SubscriptionID:    state.SubscriptionID,
+		TenantID:          state.TenantID,
+		Cloud:             state.Cloud,
+		CapturedAt:        time.Now().UTC(),
+		ToolVersion:       toolVersion,
+		ResourceProviders: state.ResourceProviders,
+		PreviewFeatures:   state.PreviewFeatures,
+		FeatureMetadata:   state.FeatureMetadata,
+	}, nil
+}
+
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file %s: %w", path, err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to deserialize snapshot from %s: %w", path, err)
+	}
+
+	return &snapshot, nil
+}
+
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot to JSON: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot to %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package plan
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	registrationDate := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		snapshot *Snapshot
+	}{
+		{
+			name: "full",
+			snapshot: &Snapshot{
+				SubscriptionID: "00000000-0000-0000-0000-000000000001",
+				TenantID:       "00000000-0000-0000-0000-000000000002",
+				Cloud:          "usgovernment",
+				CapturedAt:     time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+				ToolVersion:    "1.2.3",
+				ResourceProviders: []*armresources.Provider{
+					provider("Microsoft.Compute", "Registered"),
+					provider("Microsoft.Quantum", "NotRegistered"),
+				},
+				PreviewFeatures: []*armfeatures.FeatureResult{
+					feature("Microsoft.Compute/EncryptionAtHost", "Registered"),
+				},
+				FeatureMetadata: map[string]*FeatureMetadata{
+					"microsoft.compute/encryptionathost": {
+						Description:      "Encryption at host",
+						ApprovalType:     "AutoApproval",
+						RegistrationDate: &registrationDate,
+					},
+				},
+			},
+		},
+		{
+			name: "public cloud without features",
+			snapshot: &Snapshot{
+				SubscriptionID:    "00000000-0000-0000-0000-000000000003",
+				CapturedAt:        time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+				ResourceProviders: []*armresources.Provider{provider("Microsoft.Storage", "Registered")},
+				PreviewFeatures:   []*armfeatures.FeatureResult{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.json")
+			if err := tt.snapshot.Save(path); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			loaded, err := LoadSnapshot(path)
+			if err != nil {
+				t.Fatalf("LoadSnapshot() error = %v", err)
+			}
+			if !reflect.DeepEqual(loaded, tt.snapshot) {
+				t.Errorf("LoadSnapshot() = %+v, expected %+v", loaded, tt.snapshot)
+			}
+
+			state, err := loadSnapshotState(path, "source")
+			if err != nil {
+				t.Fatalf("loadSnapshotState() error = %v", err)
+			}
+			if state.SubscriptionID != tt.snapshot.SubscriptionID || state.Cloud != tt.snapshot.Cloud || len(state.ResourceProviders) != len(tt.snapshot.ResourceProviders) {
+				t.Errorf("loadSnapshotState() = %+v, expected the snapshot content", state)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// StaleEntry is a plan entry the current target state no longer agrees with.
+type StaleEntry struct {
+	Kind      string // RP | preview feature
+	Name      string // eg: "Microsoft.Cache", "Microsoft.Compute/EncryptionAtHost"
+	Satisfied bool   // the target already is in the state the entry was planned to reach
+	Problem   string // eg: "already registered", "planned as NotRegisteredInTarget, now UnregisteringInTarget"
+}
+
+// CheckStaleness compares the plan entries with the current target state and returns the plan without the stale
+// entries, along with the stale ones. Entries apply skips anyway are left as is, so are preview features needing
+// Microsoft approval that are pending in target: they still await the approval apply writes a support request for.
+func CheckStaleness(p *Plan, targetState *SubscriptionState) (fresh *Plan, stale []StaleEntry) {
+	rpStates := make(map[string]RegistrationState)
+	for _, rp := range targetState.ResourceProviders {
+		if rp != nil {
+			rpStates[strings.ToLower(pointer.From(rp.Namespace))] = ParseRegistrationState(pointer.From(rp.RegistrationState))
+		}
+	}
+	featureStates := make(map[string]RegistrationState)
+	for _, feat := range targetState.PreviewFeatures {
+		if feat != nil {
+			featureStates[strings.ToLower(pointer.From(feat.Name))] = ParseRegistrationState(getState(feat))
+		}
+	}
+
+	copied := *p
+	fresh = &copied
+	fresh.RpRegistrations = []RpRegistration{}
+	fresh.PreviewFeatures = []PreviewFeature{}
+
+	for _, rpReg := range p.RpRegistrations {
+		state, exists := rpStates[strings.ToLower(rpReg.Namespace)]
+		if entry, isStale := staleEntry(rpReg.Action, rpReg.Reason, state, exists); isStale {
+			entry.Kind, entry.Name = "RP", rpReg.Namespace
+			stale = append(stale, entry)
+			continue
+		}
+		fresh.RpRegistrations = append(fresh.RpRegistrations, rpReg)
+	}
+
+	for _, feature := range p.PreviewFeatures {
+		name := feature.Namespace + "/" + feature.Key
+		state, exists := featureStates[strings.ToLower(name)]
+		if feature.Approval == ApprovalManual && exists && state == StatePending {
+			fresh.PreviewFeatures = append(fresh.PreviewFeatures, feature)
+			continue
+		}
+		if entry, isStale := staleEntry(feature.Action, feature.Reason, state, exists); isStale {
+			entry.Kind, entry.Name = "preview feature", name
+			stale = append(stale, entry)
+			continue
+		}
+		fresh.PreviewFeatures = append(fresh.PreviewFeatures, feature)
+	}
+
+	return
+}
+
+// staleEntry tells whether an entry planned with the action and reason is stale given the current target state.
+// Register entries planned because of the target state must still find it, NotFoundInTarget and
+// NotRegisteredInTarget are interchangeable since both are a plain registration.
+func staleEntry(action string, reason string, state RegistrationState, exists bool) (StaleEntry, bool) {
+	if reason == ReasonUnavailableInTargetCloud || reason == ReasonPendingInSource {
+		return StaleEntry{}, false
+	}
+
+	if action == "unregister" {
+		if !exists || !state.IsRegisteredOrPending() {
+			return StaleEntry{Satisfied: true, Problem: "already unregistered"}, true
+		}
+		return StaleEntry{}, false
+	}
+
+	currentReason := ReasonNotFoundInTarget
+	if exists {
+		currentReason = targetReason(state)
+	}
+	if currentReason == "" {
+		return StaleEntry{Satisfied: true, Problem: fmt.Sprintf("already %s", state)}, true
+	}
+
+	// entries required by baseline, templates or profiles don't depend on the target state
+	plannedFromTarget := isMissingInTarget(reason) || reason == ReasonUnregisteringInTarget || reason == ReasonStuckRegisteringInTarget
+	sameRegistration := reason == currentReason || (isMissingInTarget(reason) && isMissingInTarget(currentReason))
+	if plannedFromTarget && !sameRegistration {
+		return StaleEntry{Problem: fmt.Sprintf("planned as %s, now %s", reason, currentReason)}, true
+	}
+	return StaleEntry{}, false
+}
@@ -0,0 +1,71 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+func TestCheckStaleness(t *testing.T) {
+	p := &Plan{
+		RpRegistrations: []RpRegistration{
+			{Namespace: "Microsoft.Cache", Reason: ReasonNotFoundInTarget},
+			{Namespace: "Microsoft.Web", Reason: ReasonNotRegisteredInTarget},
+			{Namespace: "Microsoft.Compute", Reason: ReasonNotRegisteredInTarget},
+			{Namespace: "Microsoft.Network", Reason: ReasonUnregisteringInTarget},
+			{Namespace: "Microsoft.Blockchain", Action: "unregister", Reason: ReasonNotRegisteredInSource},
+			{Namespace: "Microsoft.Quantum", Reason: ReasonUnavailableInTargetCloud},
+		},
+		PreviewFeatures: []PreviewFeature{
+			{Key: "EncryptionAtHost", Namespace: "Microsoft.Compute", Reason: ReasonRequiredByBaseline},
+			{Key: "Dev", Namespace: "Microsoft.DevAI", Reason: ReasonNotFoundInTarget},
+			{Key: "Gated", Namespace: "Microsoft.DevAI", Reason: ReasonNotRegisteredInTarget, Approval: ApprovalManual},
+			{Key: "GatedDone", Namespace: "Microsoft.DevAI", Reason: ReasonNotRegisteredInTarget, Approval: ApprovalManual},
+		},
+	}
+	targetState := &SubscriptionState{
+		ResourceProviders: []*armresources.Provider{
+			provider("Microsoft.Cache", "NotRegistered"),
+			provider("microsoft.web", "Registered"),
+			provider("Microsoft.Compute", "Unregistering"),
+			provider("Microsoft.Network", "Unregistering"),
+			provider("Microsoft.Blockchain", "Unregistered"),
+		},
+		PreviewFeatures: []*armfeatures.FeatureResult{
+			feature("Microsoft.Compute/EncryptionAtHost", "Pending"),
+			feature("Microsoft.DevAI/Gated", "Pending"),
+			feature("Microsoft.DevAI/GatedDone", "Registered"),
+		},
+	}
+
+	fresh, stale := CheckStaleness(p, targetState)
+
+	expectedStale := []StaleEntry{
+		{Kind: "RP", Name: "Microsoft.Web", Satisfied: true, Problem: "already Registered"},
+		{Kind: "RP", Name: "Microsoft.Compute", Problem: "planned as NotRegisteredInTarget, now UnregisteringInTarget"},
+		{Kind: "RP", Name: "Microsoft.Blockchain", Satisfied: true, Problem: "already unregistered"},
+		{Kind: "preview feature", Name: "Microsoft.Compute/EncryptionAtHost", Satisfied: true, Problem: "already Pending"},
+		{Kind: "preview feature", Name: "Microsoft.DevAI/GatedDone", Satisfied: true, Problem: "already Registered"},
+	}
+	if !reflect.DeepEqual(stale, expectedStale) {
+		t.Errorf("CheckStaleness() stale = %+v, expected %+v", stale, expectedStale)
+	}
+
+	expectedRPs := []RpRegistration{
+		{Namespace: "Microsoft.Cache", Reason: ReasonNotFoundInTarget},
+		{Namespace: "Microsoft.Network", Reason: ReasonUnregisteringInTarget},
+		{Namespace: "Microsoft.Quantum", Reason: ReasonUnavailableInTargetCloud},
+	}
+	if !reflect.DeepEqual(fresh.RpRegistrations, expectedRPs) {
+		t.Errorf("CheckStaleness() RpRegistrations = %+v, expected %+v", fresh.RpRegistrations, expectedRPs)
+	}
+	expectedFeatures := []PreviewFeature{
+		{Key: "Dev", Namespace: "Microsoft.DevAI", Reason: ReasonNotFoundInTarget},
+		{Key: "Gated", Namespace: "Microsoft.DevAI", Reason: ReasonNotRegisteredInTarget, Approval: ApprovalManual},
+	}
+	if !reflect.DeepEqual(fresh.PreviewFeatures, expectedFeatures) {
+		t.Errorf("CheckStaleness() PreviewFeatures = %+v, expected %+v", fresh.PreviewFeatures, expectedFeatures)
+	}
+}
@@ -0,0 +1,256 @@
+package plan
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armfeatures"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/flagutil"
+)
+
+// SubscriptionState is the RP and preview feature registrations of one side of the plan, either fetched live or
+// loaded from a file.
+type SubscriptionState struct {
+	Origin            string // eg: "live", "snapshot source.json"
+	TenantID          string
+	SubscriptionID    string
+	Cloud             string // public | usgovernment | china, public for states declaring required entries
+	ResourceProviders []*armresources.Provider
+	PreviewFeatures   []*armfeatures.FeatureResult
+
+	// RpSources and FeatureSources are set when the state is merged from multiple sources, they list the sources
+	// contributing each lower-cased RP namespace and "Namespace/Key" feature name.
+	RpSources      map[string][]string
+	FeatureSources map[string][]string
+
+	// RequiredReason is set when the state is a list of required entries rather than an actual subscription, every
+	// plan entry derived from it carries this reason instead of NotRegisteredInTarget | NotFoundInTarget.
+	RequiredReason string
+
+	// ResourceCounts is the number of resources by lower-cased provider namespace, only counted in used-only mode.
+	ResourceCounts map[string]int
+
+	// FeatureMetadata is keyed by lower-cased "Namespace/Key", only live and snapshot states have it.
+	FeatureMetadata map[string]*FeatureMetadata
+}
+
+// fetchState fetches the registrations of a live subscription along with the preview feature metadata.
+func fetchState(ctx context.Context, config *config.Config, kind string) (*SubscriptionState, error) {
+	return fetchStateTo(ctx, config, kind, os.Stdout)
+}
+
+// fetchStateTo is fetchState writing its progress to w, so concurrent fetches don't interleave.
+func fetchStateTo(ctx context.Context, config *config.Config, kind string, w io.Writer) (*SubscriptionState, error) {
+	state, err := fetchRegistrationsTo(ctx, config, kind, w)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(w, "🔍 Fetching preview feature metadata from %s subscription...\n", kind)
+	state.FeatureMetadata, err = getFeatureMetadata(ctx, config)
+	if err != nil {
+		// metadata is informational, the plan is still correct without it
+		fmt.Fprintf(w, "  - ⚠️  Failed to get preview feature metadata from %s subscription: %v\n", kind, err)
+	}
+
+	return state, nil
+}
+
+// FetchRegistrations fetches the RP and preview feature registrations of a live subscription, without the preview
+// feature metadata only needed for planning.
+func FetchRegistrations(ctx context.Context, config *config.Config, kind string) (*SubscriptionState, error) {
+	return fetchRegistrationsTo(ctx, config, kind, os.Stdout)
+}
+
+func fetchRegistrationsTo(ctx context.Context, config *config.Config, kind string, w io.Writer) (*SubscriptionState, error) {
+	fmt.Fprintf(w, "🔍 Fetching resource providers from %s subscription...\n", kind)
+	rps, err := getResourceProviders(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource providers from %s subscription: %w", kind, err)
+	}
+
+	fmt.Fprintf(w, "🔍 Fetching preview features from %s subscription...\n", kind)
+	features, err := getPreviewFeatures(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preview features from %s subscription: %w", kind, err)
+	}
+
+	return &SubscriptionState{
+		Origin:            "live",
+		TenantID:          config.TenantID,
+		SubscriptionID:    config.SubscriptionID,
+		Cloud:             config.Cloud,
+		ResourceProviders: rps,
+		PreviewFeatures:   features,
+	}, nil
+}
+
+func loadSnapshotState(path string, kind string) (*SubscriptionState, error) {
+	fmt.Printf("📂 Loading %s subscription snapshot from %s...\n", kind, path)
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SubscriptionState{
+		Origin:            "snapshot " + path,
+		TenantID:          snapshot.TenantID,
+		SubscriptionID:    snapshot.SubscriptionID,
+		Cloud:             snapshot.Cloud,
+		ResourceProviders: snapshot.ResourceProviders,
+		PreviewFeatures:   snapshot.PreviewFeatures,
+		FeatureMetadata:   snapshot.FeatureMetadata,
+	}, nil
+}
+
+// StateSource holds the flags selecting where the state of one side is read from, the live subscription is used when
+// none of them are set. Each flag is repeatable for sides that accept multiple subscriptions.
+type StateSource struct {
+	prefix      string
+	snapshots   flagutil.StringList
+	azProviders flagutil.StringList
+	azFeatures  flagutil.StringList
+	fleet       string
+}
+
+// RegisterFlags registers --<prefix>-snapshot, --<prefix>-az-providers and --<prefix>-az-features, or the flags
+// without a prefix when it is empty.
+func (s *StateSource) RegisterFlags(fs *flag.FlagSet, prefix string) {
+	s.prefix = prefix
+	fs.Var(&s.snapshots, s.flagName("snapshot"), "")
+	fs.Var(&s.azProviders, s.flagName("az-providers"), "")
+	fs.Var(&s.azFeatures, s.flagName("az-features"), "")
+}
+
+// registerFleetFlag registers --<prefix>-fleet, reading live subscriptions with their own credentials from the
+// matching list of a fleet file.
+func (s *StateSource) registerFleetFlag(fs *flag.FlagSet) {
+	fs.StringVar(&s.fleet, s.flagName("fleet"), "", "")
+}
+
+func (s *StateSource) flagName(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return s.prefix + "-" + name
+}
+
+func (s *StateSource) IsSet() bool {
+	return len(s.snapshots) > 0 || len(s.azProviders) > 0 || len(s.azFeatures) > 0 || s.fleet != ""
+}
+
+func (s *StateSource) Validate(allowMultiple bool) error {
+	if len(s.azProviders) != len(s.azFeatures) {
+		return fmt.Errorf("every --%s must be paired with a --%s", s.flagName("az-providers"), s.flagName("az-features"))
+	}
+	if !allowMultiple && len(s.snapshots)+len(s.azProviders) > 1 {
+		return fmt.Errorf("only one of --%s / --%s can be given", s.flagName("snapshot"), s.flagName("az-providers"))
+	}
+	return nil
+}
+
+// Resolve returns the state of every subscription selected for the side in the order they were given, side is the
+// environment variables prefix of the live subscription: "src" or "target".
+func (s *StateSource) Resolve(ctx context.Context, side string, kind string) (states []*SubscriptionState, err error) {
+	for _, path := range s.snapshots {
+		state, err := loadSnapshotState(path, kind)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+
+	for i := range s.azProviders {
+		state, err := loadAzCliState(s.azProviders[i], s.azFeatures[i], kind)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+
+	if s.fleet != "" {
+		fmt.Printf("📂 Loading fleet %s subscriptions from %s...\n", kind, s.fleet)
+		configs, err := config.LoadFleetSources(s.fleet)
+		if err != nil {
+			return nil, err
+		}
+		for _, config := range configs {
+			state, err := fetchState(ctx, config, kind+" "+config.SubscriptionID)
+			if err != nil {
+				return nil, err
+			}
+			states = append(states, state)
+		}
+	}
+
+	if len(states) > 0 {
+		// files other than snapshots don't record the cloud they were captured from
+		cloud, err := config.CloudFromEnv(side)
+		if err != nil {
+			return nil, err
+		}
+		for _, state := range states {
+			if state.Cloud == "" {
+				state.Cloud = cloud
+			}
+		}
+		return states, nil
+	}
+
+	config, err := config.BuildConfig(side)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s configuration: %w", kind, err)
+	}
+
+	state, err := fetchState(ctx, config, kind)
+	if err != nil {
+		return nil, err
+	}
+	return []*SubscriptionState{state}, nil
+}
+
+// label identifies the state among multiple sources.
+func (s *SubscriptionState) label() string {
+	if s.SubscriptionID != "" {
+		return s.SubscriptionID
+	}
+	return s.Origin
+}
+
+func (s *SubscriptionState) describe() string {
+	if s.SubscriptionID == "" {
+		return s.Origin
+	}
+	tenantID := s.TenantID
+	if tenantID == "" {
+		tenantID = "unknown"
+	}
+	return fmt.Sprintf("%s / %s (%s)", tenantID, s.SubscriptionID, s.Origin)
+}
+
+// overrideRpReasons replaces the reasons telling the entry is missing in target, the other reasons change how apply
+// registers the entry and are kept.
+func overrideRpReasons(rpRegs []RpRegistration, reason string) {
+	for i := range rpRegs {
+		if isMissingInTarget(rpRegs[i].Reason) {
+			rpRegs[i].Reason = reason
+		}
+	}
+}
+
+func overrideFeatureReasons(prFeats []PreviewFeature, reason string) {
+	for i := range prFeats {
+		if isMissingInTarget(prFeats[i].Reason) {
+			prFeats[i].Reason = reason
+		}
+	}
+}
+
+func isMissingInTarget(reason string) bool {
+	return reason == ReasonNotRegisteredInTarget || reason == ReasonNotFoundInTarget
+}
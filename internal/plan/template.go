@@ -0,0 +1,156 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+)
+
+// templateCollector walks ARM JSON templates, following inline nested deployments and linked templates referenced by
+// relativePath, and collects the provider namespace of every resource type.
+type templateCollector struct {
+	namespaces []string
+	seen       map[string]bool // lower-cased namespaces
+	types      map[string]bool // lower-cased resource types
+	visited    map[string]bool // absolute template paths
+	skipped    []string
+}
+
+func newTemplateCollector() *templateCollector {
+	return &templateCollector{
+		seen:    make(map[string]bool),
+		types:   make(map[string]bool),
+		visited: make(map[string]bool),
+	}
+}
+
+func (c *templateCollector) collectFile(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve template path %s: %w", path, err)
+	}
+	if c.visited[absPath] {
+		return nil
+	}
+	c.visited[absPath] = true
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("failed to read template file %s: %w", path, err)
+	}
+
+	var template map[string]any
+	if err := json.Unmarshal(jsonutil.StripJSONComments(data), &template); err != nil {
+		return fmt.Errorf("failed to deserialize template from %s: %w", path, err)
+	}
+
+	return c.collectTemplate(template, filepath.Dir(absPath), path)
+}
+
+func (c *templateCollector) collectTemplate(template map[string]any, dir string, origin string) error {
+	return c.collectResources(template["resources"], "", dir, origin)
+}
+
+// collectResources accepts both the resources array and the symbolic name object of languageVersion 2.0 templates.
+func (c *templateCollector) collectResources(resources any, parentType string, dir string, origin string) error {
+	switch resources := resources.(type) {
+	case []any:
+		for _, resource := range resources {
+			if err := c.collectResource(resource, parentType, dir, origin); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		for _, resource := range resources {
+			if err := c.collectResource(resource, parentType, dir, origin); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *templateCollector) collectResource(resource any, parentType string, dir string, origin string) error {
+	properties, ok := resource.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	resourceType, _ := properties["type"].(string)
+	if resourceType == "" || strings.HasPrefix(resourceType, "[") {
+		c.skipped = append(c.skipped, fmt.Sprintf("resource type %q in %s isn't a literal", resourceType, origin))
+		return nil
+	}
+
+	// child resources nested in their parent may omit the namespace and parent type, eg: "blobServices"
+	namespace, _, _ := strings.Cut(resourceType, "/")
+	if parentType != "" && !strings.Contains(namespace, ".") {
+		resourceType = parentType + "/" + resourceType
+		namespace, _, _ = strings.Cut(resourceType, "/")
+	}
+	c.addType(namespace, resourceType)
+
+	if strings.EqualFold(resourceType, "Microsoft.Resources/deployments") {
+		if err := c.collectDeployment(properties, dir, origin); err != nil {
+			return err
+		}
+	}
+
+	return c.collectResources(properties["resources"], resourceType, dir, origin)
+}
+
+func (c *templateCollector) collectDeployment(deployment map[string]any, dir string, origin string) error {
+	properties, _ := deployment["properties"].(map[string]any)
+	if properties == nil {
+		return nil
+	}
+
+	if template, ok := properties["template"].(map[string]any); ok {
+		return c.collectTemplate(template, dir, origin)
+	}
+
+	templateLink, _ := properties["templateLink"].(map[string]any)
+	if templateLink == nil {
+		return nil
+	}
+	if relativePath, ok := templateLink["relativePath"].(string); ok && !strings.HasPrefix(relativePath, "[") {
+		return c.collectFile(filepath.Join(dir, filepath.FromSlash(relativePath)))
+	}
+	c.skipped = append(c.skipped, fmt.Sprintf("linked template in %s isn't on disk, pass it with another --from-template", origin))
+	return nil
+}
+
+func (c *templateCollector) addType(namespace string, resourceType string) {
+	c.types[strings.ToLower(resourceType)] = true
+	if !c.seen[strings.ToLower(namespace)] {
+		c.seen[strings.ToLower(namespace)] = true
+		c.namespaces = append(c.namespaces, namespace)
+	}
+}
+
+// loadTemplateState converts the namespaces of the resource types deployed by the templates into a source state where
+// each of them is required.
+func loadTemplateState(paths []string) (*SubscriptionState, error) {
+	collector := newTemplateCollector()
+	for _, path := range paths {
+		fmt.Printf("📂 Loading ARM template from %s...\n", path)
+		if err := collector.collectFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Printf("  - Found %d resource types in %d namespaces\n", len(collector.types), len(collector.namespaces))
+	for _, skipped := range collector.skipped {
+		fmt.Printf("  - ⚠️  Skipping %s\n", skipped)
+	}
+
+	// symbolic name resources are walked in random order
+	sort.Strings(collector.namespaces)
+	baseline := &Baseline{ResourceProviders: collector.namespaces}
+	return baseline.toState("template "+strings.Join(paths, ", "), ReasonRequiredByTemplate), nil
+}
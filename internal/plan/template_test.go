@@ -0,0 +1,72 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTemplateCollector(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.json"), `{
+  // comments are allowed
+  "resources": [
+    {
+      "type": "Microsoft.Storage/storageAccounts",
+      "resources": [
+        { "type": "blobServices", "resources": [{ "type": "containers" }] }
+      ]
+    },
+    { "type": "[parameters('resourceType')]" },
+    {
+      "type": "Microsoft.Resources/deployments",
+      "properties": {
+        "template": {
+          "resources": { "vault": { "type": "Microsoft.KeyVault/vaults" } }
+        }
+      }
+    },
+    {
+      "type": "Microsoft.Resources/deployments",
+      "properties": { "templateLink": { "relativePath": "modules/aks.json" } }
+    },
+    {
+      "type": "Microsoft.Resources/deployments",
+      "properties": { "templateLink": { "uri": "https://example.com/net.json" } }
+    }
+  ]
+}`)
+	writeFile(t, filepath.Join(dir, "modules", "aks.json"), `{
+  "resources": [
+    { "type": "Microsoft.ContainerService/managedClusters" },
+    { "type": "microsoft.storage/storageAccounts" }
+  ]
+}`)
+
+	collector := newTemplateCollector()
+	if err := collector.collectFile(filepath.Join(dir, "main.json")); err != nil {
+		t.Fatalf("collectFile() error = %v", err)
+	}
+
+	expectedNamespaces := []string{"Microsoft.Storage", "Microsoft.Resources", "Microsoft.KeyVault", "Microsoft.ContainerService"}
+	if !reflect.DeepEqual(collector.namespaces, expectedNamespaces) {
+		t.Errorf("namespaces = %v, expected %v", collector.namespaces, expectedNamespaces)
+	}
+	if !collector.types["microsoft.storage/storageaccounts/blobservices/containers"] {
+		t.Errorf("nested child type not collected: %v", collector.types)
+	}
+	if len(collector.skipped) != 2 {
+		t.Errorf("skipped = %v, expected expression type and remote linked template", collector.skipped)
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,305 @@
+package plan
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+)
+
+//go:embed terraform_mapping.json
+var builtinTerraformMapping []byte
+
+// terraformMapping maps azurerm resource types to RP namespaces. A key ending with "*" matches every type with that
+// prefix, eg: "azurerm_storage_*", exact keys win over prefixes and longer prefixes win over shorter ones. An empty
+// namespace marks a type that doesn't require any RP.
+type terraformMapping map[string]string
+
+// loadTerraformMapping returns the built-in mapping with the entries of the override files applied on top.
+func loadTerraformMapping(overridePaths []string) (terraformMapping, error) {
+	mapping := terraformMapping{}
+	if err := json.Unmarshal(builtinTerraformMapping, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to deserialize built-in Terraform mapping: %w", err)
+	}
+
+	for _, path := range overridePaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Terraform mapping file %s: %w", path, err)
+		}
+
+		var override terraformMapping
+		if err := json.Unmarshal(jsonutil.StripJSONComments(data), &override); err != nil {
+			return nil, fmt.Errorf("failed to deserialize Terraform mapping from %s: %w", path, err)
+		}
+		for resourceType, namespace := range override {
+			mapping[resourceType] = namespace
+		}
+	}
+
+	return mapping, nil
+}
+
+func (m terraformMapping) namespaceOf(resourceType string) (namespace string, found bool) {
+	if namespace, found := m[resourceType]; found {
+		return namespace, true
+	}
+
+	longest := ""
+	for key, value := range m {
+		prefix, isPrefix := strings.CutSuffix(key, "*")
+		if isPrefix && strings.HasPrefix(resourceType, prefix) && len(prefix) >= len(longest) {
+			longest, namespace, found = prefix, value, true
+		}
+	}
+	return
+}
+
+// terraformResources are the resource types declared in Terraform configuration files.
+type terraformResources struct {
+	azurermTypes map[string]bool
+	azapiTypes   map[string]bool // eg: "Microsoft.ContainerService/managedClusters@2024-02-01"
+	skipped      []string
+}
+
+// scanTerraformDir collects the resource types of every .tf file in the directory and its sub-directories, hidden
+// directories such as .terraform and .git are skipped.
+func scanTerraformDir(dir string) (*terraformResources, error) {
+	resources := &terraformResources{
+		azurermTypes: make(map[string]bool),
+		azapiTypes:   make(map[string]bool),
+	}
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".tf" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read Terraform file %s: %w", path, err)
+		}
+		resources.scan(string(data), path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan Terraform directory %s: %w", dir, err)
+	}
+
+	return resources, nil
+}
+
+// scan looks for `resource "<type>" "<name>" {` blocks at the top level, labels may also be bare identifiers as in
+// `resource azurerm_foo bar {`, and the `type` attribute directly inside azapi_resource blocks. It only tokenizes as
+// much HCL as needed to skip comments, strings and heredocs.
+func (r *terraformResources) scan(src string, origin string) {
+	tokens := tokenizeHCL(src)
+
+	depth := 0
+	azapiDepth := -1 // depth of the body of the azapi_resource block being scanned
+	for i := 0; i < len(tokens); i++ {
+		switch tok := tokens[i]; {
+		case tok == "{":
+			depth++
+		case tok == "}":
+			depth--
+			if depth < azapiDepth {
+				azapiDepth = -1
+			}
+		case depth == 0 && tok == "resource" && i+3 < len(tokens) && isHCLLabel(tokens[i+1]) && isHCLLabel(tokens[i+2]) && tokens[i+3] == "{":
+			resourceType := tokens[i+1]
+			if isHCLString(resourceType) {
+				resourceType = unquoteHCL(resourceType)
+			}
+			if strings.HasPrefix(resourceType, "azurerm_") {
+				r.azurermTypes[resourceType] = true
+			}
+			if resourceType == "azapi_resource" {
+				azapiDepth = 1
+			}
+			depth++
+			i += 3
+		case depth == azapiDepth && tok == "type" && i+2 < len(tokens) && tokens[i+1] == "=":
+			if value := tokens[i+2]; isHCLString(value) && !strings.Contains(value, "${") {
+				r.azapiTypes[unquoteHCL(value)] = true
+			} else {
+				r.skipped = append(r.skipped, fmt.Sprintf("azapi_resource type %s in %s isn't a literal", value, origin))
+			}
+			i += 2
+		}
+	}
+}
+
+// tokenizeHCL splits HCL source into identifiers, quoted strings (kept with their quotes) and single character
+// punctuation, dropping comments and heredocs.
+func tokenizeHCL(src string) (tokens []string) {
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '#' || strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return
+			}
+			i += end + 4
+		case strings.HasPrefix(src[i:], "<<"):
+			i = skipHeredoc(src, i)
+		case c == '"':
+			end := endOfHCLString(src, i)
+			tokens = append(tokens, src[i:end])
+			i = end
+		case isHCLIdentChar(c):
+			start := i
+			for i < len(src) && isHCLIdentChar(src[i]) {
+				i++
+			}
+			tokens = append(tokens, src[start:i])
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return
+}
+
+// endOfHCLString returns the index after the closing quote of the string starting at start, skipping escapes and
+// ${...} interpolations which may contain quotes themselves.
+func endOfHCLString(src string, start int) int {
+	for i := start + 1; i < len(src); i++ {
+		switch {
+		case src[i] == '\\':
+			i++
+		case src[i] == '"':
+			return i + 1
+		case strings.HasPrefix(src[i:], "${"):
+			depth := 0
+			for i < len(src) {
+				if src[i] == '{' {
+					depth++
+				} else if src[i] == '}' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				} else if src[i] == '"' {
+					i = endOfHCLString(src, i) - 1
+				}
+				i++
+			}
+		}
+	}
+	return len(src)
+}
+
+// skipHeredoc returns the index after the closing marker of the heredoc starting at start, eg: <<-EOT ... EOT.
+func skipHeredoc(src string, start int) int {
+	lineEnd := strings.IndexByte(src[start:], '\n')
+	if lineEnd < 0 {
+		return len(src)
+	}
+	marker := strings.TrimSpace(strings.TrimLeft(src[start+2:start+lineEnd], "-"))
+
+	i := start + lineEnd + 1
+	for i < len(src) {
+		end := strings.IndexByte(src[i:], '\n')
+		if end < 0 {
+			return len(src)
+		}
+		if strings.TrimSpace(src[i:i+end]) == marker {
+			return i + end + 1
+		}
+		i += end + 1
+	}
+	return len(src)
+}
+
+func isHCLIdentChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isHCLLabel reports whether a token can be a block label, a quoted string or a bare identifier.
+func isHCLLabel(token string) bool {
+	return isHCLString(token) || isHCLIdentChar(token[0])
+}
+
+func isHCLString(token string) bool {
+	return len(token) >= 2 && token[0] == '"' && token[len(token)-1] == '"'
+}
+
+func unquoteHCL(token string) string {
+	return token[1 : len(token)-1]
+}
+
+// loadTerraformState converts the namespaces of the resources declared in the Terraform directories into a source
+// state where each of them is required.
+func loadTerraformState(dirs []string, mappingPaths []string) (*SubscriptionState, error) {
+	mapping, err := loadTerraformMapping(mappingPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make(map[string]string) // lower-cased -> as declared
+	var unknown []string
+	for _, dir := range dirs {
+		fmt.Printf("📂 Scanning Terraform configuration in %s...\n", dir)
+		resources, err := scanTerraformDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for resourceType := range resources.azurermTypes {
+			namespace, found := mapping.namespaceOf(resourceType)
+			if !found {
+				unknown = append(unknown, resourceType)
+			} else if namespace != "" {
+				namespaces[strings.ToLower(namespace)] = namespace
+			}
+		}
+		for resourceType := range resources.azapiTypes {
+			namespace, _, _ := strings.Cut(resourceType, "/")
+			namespaces[strings.ToLower(namespace)] = namespace
+		}
+
+		fmt.Printf("  - Found %d azurerm and %d azapi resource types\n", len(resources.azurermTypes), len(resources.azapiTypes))
+		for _, skipped := range resources.skipped {
+			fmt.Printf("  - ⚠️  Skipping %s\n", skipped)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		fmt.Printf("❓ %d Terraform resource types aren't mapped to an RP namespace, add them with --terraform-mapping:\n", len(unknown))
+		for _, resourceType := range unknown {
+			fmt.Printf("  - %s\n", resourceType)
+		}
+	}
+
+	baseline := &Baseline{}
+	for _, namespace := range namespaces {
+		baseline.ResourceProviders = append(baseline.ResourceProviders, namespace)
+	}
+	sort.Strings(baseline.ResourceProviders)
+
+	return baseline.toState("terraform "+strings.Join(dirs, ", "), ReasonRequiredByTerraform), nil
+}
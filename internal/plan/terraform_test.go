@@ -0,0 +1,176 @@
+package plan
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTerraformScan(t *testing.T) {
+	src := `
+# resource "azurerm_commented_out" "x" {}
+resource "azurerm_kubernetes_cluster" "aks" {
+  name = "aks-${var.env}-${lookup(var.names, "aks")}"
+  identity {
+    type = "SystemAssigned"
+  }
+  tags = {
+    note = <<-EOT
+      resource "azurerm_in_heredoc" "x" {}
+    EOT
+  }
+}
+
+/* resource "azurerm_in_block_comment" "x" {} */
+resource "azapi_resource" "fleet" {
+  type      = "Microsoft.ContainerService/fleets@2023-10-15"
+  parent_id = azurerm_resource_group.rg.id
+  body = {
+    properties = { type = "NotTheResourceType" }
+  }
+}
+
+resource "azapi_resource" "dynamic" {
+  type = "${var.namespace}/things@2024-01-01"
+}
+
+resource "random_string" "suffix" {
+  length = 6
+}
+`
+	resources := &terraformResources{azurermTypes: map[string]bool{}, azapiTypes: map[string]bool{}}
+	resources.scan(src, "main.tf")
+
+	expectedAzurerm := map[string]bool{"azurerm_kubernetes_cluster": true}
+	if !reflect.DeepEqual(resources.azurermTypes, expectedAzurerm) {
+		t.Errorf("azurermTypes = %v, expected %v", resources.azurermTypes, expectedAzurerm)
+	}
+	expectedAzapi := map[string]bool{"Microsoft.ContainerService/fleets@2023-10-15": true}
+	if !reflect.DeepEqual(resources.azapiTypes, expectedAzapi) {
+		t.Errorf("azapiTypes = %v, expected %v", resources.azapiTypes, expectedAzapi)
+	}
+	if len(resources.skipped) != 1 {
+		t.Errorf("skipped = %v, expected the interpolated azapi type", resources.skipped)
+	}
+}
+
+func TestTerraformScanSyntax(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected map[string]bool
+	}{
+		{
+			name:     "identifier labels",
+			src:      "resource azurerm_foo bar {\n}\nresource \"azurerm_bar\" baz {}",
+			expected: map[string]bool{"azurerm_foo": true, "azurerm_bar": true},
+		},
+		{
+			name: "comments",
+			src: `// resource "azurerm_line_comment" "x" {}
+# resource azurerm_hash_comment x {}
+/*
+resource "azurerm_block_comment" "x" {}
+*/
+resource "azurerm_foo" "x" { # trailing { comment
+  name = "x" // another }
+}`,
+			expected: map[string]bool{"azurerm_foo": true},
+		},
+		{
+			name: "heredocs",
+			src: `resource "azurerm_foo" "x" {
+  script = <<EOT
+    "unbalanced quote }
+EOT
+  policy = <<-JSON
+    { "resource": "azurerm_in_heredoc" }
+    JSON
+}
+resource "azurerm_bar" "y" {}`,
+			expected: map[string]bool{"azurerm_foo": true, "azurerm_bar": true},
+		},
+		{
+			name: "interpolation containing quotes",
+			src: `resource "azurerm_foo" "x" {
+  name = "${replace(var.name, "}", "")}-${format("%s\"", "{")}"
+  tags = { note = "escaped \" resource \"azurerm_in_string\" {" }
+}
+resource "azurerm_bar" "y" {}`,
+			expected: map[string]bool{"azurerm_foo": true, "azurerm_bar": true},
+		},
+		{
+			name:     "nested blocks aren't top level",
+			src:      "module \"m\" {\n  resource azurerm_nested x {}\n}",
+			expected: map[string]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources := &terraformResources{azurermTypes: map[string]bool{}, azapiTypes: map[string]bool{}}
+			resources.scan(tt.src, "main.tf")
+			if !reflect.DeepEqual(resources.azurermTypes, tt.expected) {
+				t.Errorf("azurermTypes = %v, expected %v", resources.azurermTypes, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTerraformMappingNamespaceOf(t *testing.T) {
+	mapping, err := loadTerraformMapping(nil)
+	if err != nil {
+		t.Fatalf("loadTerraformMapping() error = %v", err)
+	}
+	mapping["azurerm_custom_thing"] = "Microsoft.Custom"
+	mapping["azurerm_ignored"] = ""
+
+	tests := []struct {
+		resourceType string
+		namespace    string
+		found        bool
+	}{
+		{resourceType: "azurerm_storage_account", namespace: "Microsoft.Storage", found: true},
+		{resourceType: "azurerm_management_group_policy_assignment", namespace: "Microsoft.Authorization", found: true},
+		{resourceType: "azurerm_management_group_subscription_association", namespace: "Microsoft.Management", found: true},
+		{resourceType: "azurerm_monitor_workspace", namespace: "Microsoft.Monitor", found: true},
+		{resourceType: "azurerm_custom_thing", namespace: "Microsoft.Custom", found: true},
+		{resourceType: "azurerm_ignored", namespace: "", found: true},
+		{resourceType: "azurerm_unknown_thing", namespace: "", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			namespace, found := mapping.namespaceOf(tt.resourceType)
+			if namespace != tt.namespace || found != tt.found {
+				t.Errorf("namespaceOf() = %q, %v, expected %q, %v", namespace, found, tt.namespace, tt.found)
+			}
+		})
+	}
+}
+
+func TestLoadTerraformState(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "main.tf"), `resource "azurerm_storage_account" "sa" {}
+resource "azurerm_unknown_thing" "x" {}`)
+	writeFile(t, filepath.Join(dir, "modules", "kv", "main.tf"), `resource "azurerm_key_vault" "kv" {}`)
+	writeFile(t, filepath.Join(dir, ".terraform", "modules", "main.tf"), `resource "azurerm_redis_cache" "r" {}`)
+	writeFile(t, filepath.Join(dir, "mapping.json"), `{ "azurerm_unknown_thing": "Microsoft.Unknown" }`)
+
+	state, err := loadTerraformState([]string{dir}, []string{filepath.Join(dir, "mapping.json")})
+	if err != nil {
+		t.Fatalf("loadTerraformState() error = %v", err)
+	}
+
+	var namespaces []string
+	for _, rp := range state.ResourceProviders {
+		namespaces = append(namespaces, *rp.Namespace)
+	}
+	expected := []string{"Microsoft.KeyVault", "Microsoft.Storage", "Microsoft.Unknown"}
+	if !reflect.DeepEqual(namespaces, expected) {
+		t.Errorf("namespaces = %v, expected %v", namespaces, expected)
+	}
+	if state.RequiredReason != "RequiredByTerraform" {
+		t.Errorf("RequiredReason = %q, expected RequiredByTerraform", state.RequiredReason)
+	}
+}
@@ -0,0 +1,120 @@
+package profiles
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/featurename"
+	"github.com/gerrytan/azsubsyn/internal/jsonutil"
+)
+
+//go:embed catalog/*.jsonc
+var catalog embed.FS
+
+const profileExt = ".jsonc"
+
+// Profile lists the RPs and preview features a scenario needs, eg: an AKS cluster.
+type Profile struct {
+	Name              string   `json:"-"`
+	Origin            string   `json:"-"`                 // "built-in" or the local file path
+	Description       string   `json:"description"`       // eg: "Azure Kubernetes Service cluster"
+	ResourceProviders []string `json:"resourceProviders"` // eg: "Microsoft.ContainerService"
+	PreviewFeatures   []string `json:"previewFeatures"`   // eg: "Microsoft.Compute/EncryptionAtHost"
+}
+
+// LocalDir is where local profiles are looked up by name, next to the built-in ones: ~/.azsubsyn/profiles.
+func LocalDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".azsubsyn", "profiles")
+}
+
+// Load returns the profile with the given name, or read from the given file path when it ends with .json / .jsonc.
+// Local profiles take precedence over built-in ones of the same name.
+func Load(nameOrPath string) (*Profile, error) {
+	if ext := filepath.Ext(nameOrPath); ext == ".json" || ext == profileExt {
+		return loadFile(nameOrPath)
+	}
+
+	if dir := LocalDir(); dir != "" {
+		path := filepath.Join(dir, nameOrPath+profileExt)
+		if _, err := os.Stat(path); err == nil {
+			return loadFile(path)
+		}
+	}
+
+	data, err := catalog.ReadFile("catalog/" + nameOrPath + profileExt)
+	if err != nil {
+		return nil, fmt.Errorf("unknown profile %q, see `azsubsyn profiles list`", nameOrPath)
+	}
+	return parse(data, nameOrPath, "built-in")
+}
+
+// List returns the built-in and local profiles sorted by name, a local profile hides the built-in one of the same name.
+func List() ([]*Profile, error) {
+	byName := make(map[string]*Profile)
+
+	entries, err := catalog.ReadDir("catalog")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in profiles: %w", err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), profileExt)
+		profile, err := Load(name)
+		if err != nil {
+			return nil, err
+		}
+		byName[name] = profile
+	}
+
+	if dir := LocalDir(); dir != "" {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*"+profileExt))
+		for _, path := range paths {
+			profile, err := loadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			byName[profile.Name] = profile
+		}
+	}
+
+	var profiles []*Profile
+	for _, profile := range byName {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+func loadFile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile file %s: %w", path, err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return parse(data, name, path)
+}
+
+func parse(data []byte, name string, origin string) (*Profile, error) {
+	var profile Profile
+	if err := json.Unmarshal(jsonutil.StripJSONComments(data), &profile); err != nil {
+		return nil, fmt.Errorf("failed to deserialize profile %s from %s: %w", name, origin, err)
+	}
+	profile.Name = name
+	profile.Origin = origin
+
+	for _, feature := range profile.PreviewFeatures {
+		if _, _, err := featurename.Split(feature); err != nil {
+			return nil, fmt.Errorf("bad preview feature %q in profile %s: %w", feature, name, err)
+		}
+	}
+
+	return &profile, nil
+}
@@ -0,0 +1,67 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	profiles, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, profile := range profiles {
+		names[profile.Name] = true
+		if profile.Description == "" || len(profile.ResourceProviders) == 0 {
+			t.Errorf("profile %s is missing a description or resource providers", profile.Name)
+		}
+	}
+	for _, name := range []string{"aks", "avd", "databricks", "sql-mi", "networking-hub"} {
+		if !names[name] {
+			t.Errorf("built-in profile %s not found", name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	localDir := filepath.Join(home, ".azsubsyn", "profiles")
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	local := `{ "description": "Our AKS", "resourceProviders": ["Microsoft.ContainerService"] }`
+	if err := os.WriteFile(filepath.Join(localDir, "aks.jsonc"), []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "team.json")
+	if err := os.WriteFile(file, []byte(`{ "previewFeatures": ["AllowX"] }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := Load("aks")
+	if err != nil {
+		t.Fatalf("Load(aks) error = %v", err)
+	}
+	if profile.Description != "Our AKS" {
+		t.Errorf("local profile didn't take precedence, got %q", profile.Description)
+	}
+
+	if profile, err := Load("sql-mi"); err != nil || profile.Origin != "built-in" {
+		t.Errorf("Load(sql-mi) = %v, %v, expected built-in profile", profile, err)
+	}
+
+	if _, err := Load("unknown"); err == nil {
+		t.Errorf("Load(unknown) expected error")
+	}
+
+	if _, err := Load(file); err == nil {
+		t.Errorf("Load(%s) expected error for feature without namespace", file)
+	}
+}
@@ -0,0 +1,72 @@
+package profiles
+
+import (
+	"fmt"
+	"os"
+)
+
+func RunProfiles() error {
+	if len(os.Args) < 3 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	switch subcommand := os.Args[2]; {
+	case subcommand == "list" && len(os.Args) == 3:
+		return runList()
+	case subcommand == "show" && len(os.Args) == 4:
+		return runShow(os.Args[3])
+	case subcommand == "-h" || subcommand == "--help" || subcommand == "help":
+		printUsage()
+		os.Exit(0)
+	default:
+		printUsage()
+		os.Exit(1)
+	}
+	return nil
+}
+
+func runList() error {
+	profiles, err := List()
+	if err != nil {
+		return fmt.Errorf("❌ Failed to list profiles: %w", err)
+	}
+
+	fmt.Println("📚 Available profiles:")
+	for _, profile := range profiles {
+		fmt.Printf("  - %-16s %s (%s)\n", profile.Name, profile.Description, profile.Origin)
+	}
+	return nil
+}
+
+func runShow(nameOrPath string) error {
+	profile, err := Load(nameOrPath)
+	if err != nil {
+		return fmt.Errorf("❌ %w", err)
+	}
+
+	fmt.Printf("📄 Profile %s (%s)\n", profile.Name, profile.Origin)
+	fmt.Printf("  %s\n", profile.Description)
+	fmt.Printf("Resource providers (%d):\n", len(profile.ResourceProviders))
+	for _, namespace := range profile.ResourceProviders {
+		fmt.Printf("  - %s\n", namespace)
+	}
+	fmt.Printf("Preview features (%d):\n", len(profile.PreviewFeatures))
+	for _, feature := range profile.PreviewFeatures {
+		fmt.Printf("  - %s\n", feature)
+	}
+	return nil
+}
+
+func printUsage() {
+	fmt.Println("azsubsyn profiles - Browse the scenario profiles usable with `azsubsyn plan --profile`")
+	fmt.Println()
+	fmt.Println("USAGE:")
+	fmt.Println("  azsubsyn profiles list")
+	fmt.Println("  azsubsyn profiles show <name>|<file>")
+	fmt.Println()
+	fmt.Println("DESCRIPTION:")
+	fmt.Println("  Profiles list the RPs and preview features a scenario needs, eg: aks, avd, databricks, sql-mi and")
+	fmt.Println("  networking-hub. Local profiles are read from ~/.azsubsyn/profiles/<name>.jsonc and take precedence over")
+	fmt.Println("  the built-in ones of the same name, a profile file can also be given by path.")
+}
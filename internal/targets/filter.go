@@ -0,0 +1,75 @@
+package targets
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// subscriptionFilter selects subscriptions by display name and tags, every criteria set must match.
+type subscriptionFilter struct {
+	nameGlob  string         // eg: "prod-*"
+	nameRegex *regexp.Regexp // eg: "^prod-(weu|neu)-\d+$"
+	tags      []string       // eg: "env=prod", or "env" to only require the tag to exist
+}
+
+func newSubscriptionFilter(nameGlob string, nameRegex string, tags []string) (*subscriptionFilter, error) {
+	filter := &subscriptionFilter{
+		nameGlob: strings.ToLower(nameGlob),
+		tags:     tags,
+	}
+
+	if _, err := path.Match(filter.nameGlob, ""); err != nil {
+		return nil, fmt.Errorf("bad name pattern %q: %w", nameGlob, err)
+	}
+
+	if nameRegex != "" {
+		re, err := regexp.Compile("(?i)" + nameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("bad name regex %q: %w", nameRegex, err)
+		}
+		filter.nameRegex = re
+	}
+
+	return filter, nil
+}
+
+func (f *subscriptionFilter) isSet() bool {
+	return f.nameGlob != "" || f.nameRegex != nil || len(f.tags) > 0
+}
+
+func (f *subscriptionFilter) matches(sub *armsubscriptions.Subscription) bool {
+	name := pointer.From(sub.DisplayName)
+
+	if f.nameGlob != "" {
+		if matched, _ := path.Match(f.nameGlob, strings.ToLower(name)); !matched {
+			return false
+		}
+	}
+
+	if f.nameRegex != nil && !f.nameRegex.MatchString(name) {
+		return false
+	}
+
+	for _, tag := range f.tags {
+		key, value, hasValue := strings.Cut(tag, "=")
+		if !hasTag(sub.Tags, key, value, hasValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasTag(tags map[string]*string, key string, value string, matchValue bool) bool {
+	for k, v := range tags {
+		if strings.EqualFold(k, key) && (!matchValue || strings.EqualFold(pointer.From(v), value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package targets
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+func TestSubscriptionFilterMatches(t *testing.T) {
+	sub := &armsubscriptions.Subscription{
+		DisplayName: pointer.To("Prod-WEU-01"),
+		Tags: map[string]*string{
+			"Env":  pointer.To("prod"),
+			"team": pointer.To("platform"),
+		},
+	}
+
+	tests := []struct {
+		name      string
+		nameGlob  string
+		nameRegex string
+		tags      []string
+		expected  bool
+	}{
+		{name: "No criteria", expected: true},
+		{name: "Glob match ignores case", nameGlob: "prod-*", expected: true},
+		{name: "Glob mismatch", nameGlob: "dev-*", expected: false},
+		{name: "Regex match", nameRegex: `^prod-(weu|neu)-\d+$`, expected: true},
+		{name: "Regex mismatch", nameRegex: `^prod-neu`, expected: false},
+		{name: "Tag value match ignores key case", tags: []string{"env=prod"}, expected: true},
+		{name: "Tag value mismatch", tags: []string{"env=dev"}, expected: false},
+		{name: "Tag exists", tags: []string{"team"}, expected: true},
+		{name: "Tag missing", tags: []string{"costCenter"}, expected: false},
+		{name: "All criteria must match", nameGlob: "prod-*", tags: []string{"env=prod", "team=core"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newSubscriptionFilter(tt.nameGlob, tt.nameRegex, tt.tags)
+			if err != nil {
+				t.Fatalf("newSubscriptionFilter() error = %v", err)
+			}
+
+			if actual := filter.matches(sub); actual != tt.expected {
+				t.Errorf("matches() = %v, expected %v", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewSubscriptionFilterBadPattern(t *testing.T) {
+	if _, err := newSubscriptionFilter("prod-[", "", nil); err == nil {
+		t.Error("newSubscriptionFilter() expected error for bad glob")
+	}
+	if _, err := newSubscriptionFilter("", "prod-(", nil); err == nil {
+		t.Error("newSubscriptionFilter() expected error for bad regex")
+	}
+}
@@ -0,0 +1,99 @@
+package targets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+const managementGroupsAPIVersion = "2020-05-01"
+
+type managementGroupDescendant struct {
+	ID   string `json:"id"`
+	Type string `json:"type"` // eg: "Microsoft.Management/managementGroups/subscriptions"
+	Name string `json:"name"` // subscription ID for subscriptions, group ID for groups
+}
+
+type managementGroupDescendantsPage struct {
+	Value    []managementGroupDescendant `json:"value"`
+	NextLink string                      `json:"nextLink"`
+}
+
+// subscriptionsInManagementGroup returns the IDs of every subscription under the management group, including those in
+// nested groups. The descendants API already flattens the whole hierarchy below the group.
+func subscriptionsInManagementGroup(ctx context.Context, cred azcore.TokenCredential, options *arm.ClientOptions, groupID string) (subscriptionIDs []string, err error) {
+	client, err := arm.NewClient("github.com/gerrytan/azsubsyn", "v1.0.0", cred, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ARM client: %w", err)
+	}
+
+	nextLink := runtime.JoinPaths(client.Endpoint(), "providers/Microsoft.Management/managementGroups", url.PathEscape(groupID), "descendants") +
+		"?api-version=" + managementGroupsAPIVersion
+
+	for nextLink != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, nextLink)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create management group descendants request: %w", err)
+		}
+
+		resp, err := client.Pipeline().Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get management group %s descendants: %w", groupID, err)
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, fmt.Errorf("failed to get management group %s descendants: %w", groupID, runtime.NewResponseError(resp))
+		}
+
+		var page managementGroupDescendantsPage
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, fmt.Errorf("failed to deserialize management group %s descendants: %w", groupID, err)
+		}
+
+		for _, descendant := range page.Value {
+			if strings.EqualFold(descendant.Type, "Microsoft.Management/managementGroups/subscriptions") {
+				subscriptionIDs = append(subscriptionIDs, descendant.Name)
+			}
+		}
+
+		nextLink = page.NextLink
+	}
+
+	return
+}
+
+// listSubscriptions returns every subscription the credential can see keyed by lower-cased subscription ID.
+func listSubscriptions(ctx context.Context, cred azcore.TokenCredential, options *arm.ClientOptions) (map[string]*armsubscriptions.Subscription, error) {
+	client, err := armsubscriptions.NewClient(cred, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscriptions client: %w", err)
+	}
+
+	subs := make(map[string]*armsubscriptions.Subscription)
+	pager := client.NewListPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get subscriptions page: %w", err)
+		}
+
+		for _, sub := range page.Value {
+			subs[strings.ToLower(pointer.From(sub.SubscriptionID))] = sub
+		}
+	}
+
+	return subs, nil
+}
+
+// isActive reports whether the subscription can be synced, anything other than Enabled (eg: Warned, PastDue,
+// Disabled) is skipped with a note.
+func isActive(sub *armsubscriptions.Subscription) bool {
+	return sub.State != nil && *sub.State == armsubscriptions.SubscriptionStateEnabled
+}
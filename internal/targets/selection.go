@@ -0,0 +1,140 @@
+package targets
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gerrytan/azsubsyn/internal/config"
+	"github.com/gerrytan/azsubsyn/internal/credential"
+	"github.com/gerrytan/azsubsyn/internal/flagutil"
+	"github.com/gerrytan/azsubsyn/internal/pointer"
+)
+
+// Selection holds the flags selecting multiple target subscriptions. When none are set the single target configured
+// via AZSUBSYN_TARGET_* environment variables is used.
+type Selection struct {
+	fleet           string
+	managementGroup string
+	nameGlob        string
+	nameRegex       string
+	tags            flagutil.StringList
+}
+
+func (s *Selection) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&s.fleet, "fleet", "", "")
+	fs.StringVar(&s.managementGroup, "target-management-group", "", "")
+	fs.StringVar(&s.nameGlob, "target-name", "", "")
+	fs.StringVar(&s.nameRegex, "target-name-regex", "", "")
+	fs.Var(&s.tags, "target-tag", "")
+}
+
+func (s *Selection) IsSet() bool {
+	return s.fleet != "" || s.isDiscovered()
+}
+
+func (s *Selection) isDiscovered() bool {
+	return s.managementGroup != "" || s.nameGlob != "" || s.nameRegex != "" || len(s.tags) > 0
+}
+
+func (s *Selection) Resolve(ctx context.Context) (configs []*config.Config, err error) {
+	if s.fleet != "" && s.isDiscovered() {
+		return nil, fmt.Errorf("--fleet can't be combined with --target-management-group / --target-name / --target-name-regex / --target-tag")
+	}
+
+	if s.fleet != "" {
+		fmt.Printf("📂 Loading fleet targets from %s...\n", s.fleet)
+		return config.LoadFleet(s.fleet)
+	}
+
+	if s.isDiscovered() {
+		filter, err := newSubscriptionFilter(s.nameGlob, s.nameRegex, s.tags)
+		if err != nil {
+			return nil, err
+		}
+		return discoverTargets(ctx, s.managementGroup, filter)
+	}
+
+	targetConfig, err := config.BuildConfig("target")
+	if err != nil {
+		return nil, err
+	}
+	return []*config.Config{targetConfig}, nil
+}
+
+// discoverTargets turns every active subscription that matches the filter into a target sharing the target service
+// principal. The candidates are the subscriptions under the management group if given, otherwise every subscription
+// the target service principal can see.
+func discoverTargets(ctx context.Context, groupID string, filter *subscriptionFilter) (configs []*config.Config, err error) {
+	principal, err := config.BuildPrincipalConfig("target")
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := credential.BuildCredential(principal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build target credential: %w", err)
+	}
+
+	options := credential.ClientOptions(principal)
+	subs, err := listSubscriptions(ctx, cred, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var subscriptionIDs []string
+	if groupID != "" {
+		fmt.Printf("🔍 Expanding management group %s...\n", groupID)
+		subscriptionIDs, err = subscriptionsInManagementGroup(ctx, cred, options, groupID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		fmt.Printf("🔍 Selecting from %d subscriptions visible to the target credential...\n", len(subs))
+		for _, sub := range subs {
+			subscriptionIDs = append(subscriptionIDs, pointer.From(sub.SubscriptionID))
+		}
+		sort.Slice(subscriptionIDs, func(i, j int) bool {
+			return strings.ToLower(pointer.From(subs[strings.ToLower(subscriptionIDs[i])].DisplayName)) <
+				strings.ToLower(pointer.From(subs[strings.ToLower(subscriptionIDs[j])].DisplayName))
+		})
+	}
+
+	filteredOut := 0
+	for _, subscriptionID := range subscriptionIDs {
+		sub, exists := subs[strings.ToLower(subscriptionID)]
+		if !exists {
+			fmt.Printf("  - ⚠️  Skipped %s: not accessible with the target credential\n", subscriptionID)
+			continue
+		}
+		if !filter.matches(sub) {
+			filteredOut++
+			continue
+		}
+		if !isActive(sub) {
+			fmt.Printf("  - ⚠️  Skipped %s (%s): subscription state is %s\n", subscriptionID, pointer.From(sub.DisplayName), pointer.From(sub.State))
+			continue
+		}
+
+		fmt.Printf("  - ✅ %s (%s)\n", subscriptionID, pointer.From(sub.DisplayName))
+		configs = append(configs, &config.Config{
+			ClientID:       principal.ClientID,
+			ClientSecret:   principal.ClientSecret,
+			TenantID:       principal.TenantID,
+			SubscriptionID: pointer.From(sub.SubscriptionID),
+			Cloud:          principal.Cloud,
+		})
+	}
+
+	if filter.isSet() {
+		fmt.Printf("  - ℹ️  %d subscriptions didn't match the name / tag filter\n", filteredOut)
+	}
+
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no active subscriptions matched the target selection")
+	}
+
+	return
+}
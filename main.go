@@ -5,8 +5,11 @@ import (
 	"os"
 
 	"github.com/gerrytan/azsubsyn/internal/apply"
+	"github.com/gerrytan/azsubsyn/internal/check"
+	"github.com/gerrytan/azsubsyn/internal/compare"
 	"github.com/gerrytan/azsubsyn/internal/credential"
 	"github.com/gerrytan/azsubsyn/internal/plan"
+	"github.com/gerrytan/azsubsyn/internal/profiles"
 )
 
 var Version = "dev-build"
@@ -29,7 +32,12 @@ func main() {
 			os.Exit(1)
 		}
 	case "plan":
-		if err := plan.RunPlan(); err != nil {
+		if err := plan.RunPlan(Version); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "snapshot":
+		if err := plan.RunSnapshot(Version); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -38,6 +46,21 @@ func main() {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
+	case "check":
+		if err := check.RunCheck(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "compare":
+		if err := compare.RunCompare(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "profiles":
+		if err := profiles.RunProfiles(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case "version", "-v", "--version":
 		fmt.Printf("version: %s\ngit commit SHA: %s\nbuild number: %s\nbuild date: %s\n",
 			Version, GitCommitSHA, BuildNumber, BuildDate)
@@ -62,6 +85,10 @@ func printUsage() {
 	fmt.Println("  credcheck    Check credentials and connectivity to both source and target subscriptions")
 	fmt.Println("  plan         Scan unregistered RPs and preview feature in the target subscription and save the plan to a file")
 	fmt.Println("  apply        Apply the plan file to the target subscription")
+	fmt.Println("  snapshot     Capture RP and preview feature registrations of a subscription to a file")
+	fmt.Println("  check        Assert RP and preview feature registrations of a subscription against rules")
+	fmt.Println("  compare      Report resource types the source can deploy in a region and the target can't")
+	fmt.Println("  profiles     List and show the built-in and local scenario profiles")
 	fmt.Println("  version      Show version information")
 	fmt.Println("  help         Show this help message")
 	fmt.Println()